@@ -11,6 +11,7 @@ import (
 
 	"github.com/qantik/nevv/chains"
 	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/export"
 )
 
 func init() {
@@ -20,10 +21,18 @@ func init() {
 		Cast{}, CastReply{},
 		Shuffle{}, ShuffleReply{},
 		Decrypt{}, DecryptReply{},
+		Reshare{}, ReshareReply{},
 		GetBox{}, GetBoxReply{},
+		GetDiscarded{}, GetDiscardedReply{},
+		GetTurnout{}, GetTurnoutReply{},
+		GetStats{}, GetStatsReply{},
+		Job{}, GetJob{}, GetJobReply{},
 		GetMixes{}, GetMixesReply{},
 		GetPartials{}, GetPartialsReply{},
 		Reconstruct{}, ReconstructReply{},
+		Tally{}, TallyReply{},
+		GetResult{}, GetResultReply{},
+		Export{}, ExportReply{},
 		Ping{},
 	)
 }
@@ -72,14 +81,15 @@ type LinkReply struct {
 	ID skipchain.SkipBlockID // ID of the master skipchain.
 }
 type Open struct {
-	Token    string                // Token for authentication.
-	ID       skipchain.SkipBlockID // ID of the master skipchain.
-	Election *chains.Election      // Election object.
+	Token     string                // Token for authentication.
+	ID        skipchain.SkipBlockID // ID of the master skipchain.
+	Election  *chains.Election      // Election object.
+	Threshold uint32                // Threshold of the DKG, 0 for the default.
 }
 
 type OpenReply struct {
 	ID  skipchain.SkipBlockID // ID of the election skipchain.
-	Key kyber.Point           // Key assigned by the DKG.
+	Job string                // Job running the DKG.
 }
 
 type Cast struct {
@@ -95,14 +105,29 @@ type Shuffle struct {
 	ID    skipchain.SkipBlockID // ID of the election skipchain.
 }
 
-type ShuffleReply struct{}
+type ShuffleReply struct {
+	Job string // Job running the shuffle.
+}
+
+type Reshare struct {
+	Token     string                // Token for authentication.
+	ID        skipchain.SkipBlockID // ID of the election skipchain.
+	Roster    *onet.Roster          // Roster taking over the secret, a subset of the current one.
+	Threshold uint32                // Threshold of the new roster, 0 for the default.
+}
+
+type ReshareReply struct {
+	Job string // Job running the resharing.
+}
 
 type Decrypt struct {
 	Token string                // Token for authentication.
 	ID    skipchain.SkipBlockID // ID of the election skipchain.
 }
 
-type DecryptReply struct{}
+type DecryptReply struct {
+	Job string // Job running the decryption.
+}
 
 type GetBox struct {
 	Token string                // Token for authentication.
@@ -113,6 +138,70 @@ type GetBoxReply struct {
 	Box *chains.Box // Box of encrypted ballots.
 }
 
+type GetDiscarded struct {
+	Token string                // Token for authentication.
+	ID    skipchain.SkipBlockID // ID of the election skipchain.
+}
+
+type GetDiscardedReply struct {
+	Policy    uint32 // Policy is the re-voting policy of the election.
+	Discarded uint32 // Discarded is the number of superseded ballots.
+}
+
+type GetTurnout struct {
+	Token string                // Token for authentication.
+	ID    skipchain.SkipBlockID // ID of the election skipchain.
+}
+
+type GetTurnoutReply struct {
+	Voted  uint32   // Voted is the number of distinct eligible voters who cast.
+	Roll   uint32   // Roll is the number of eligible voters.
+	Quorum uint32   // Quorum is the minimum turnout in percent of the roll.
+	Void   bool     // Void signals that the election closed below its quorum.
+	Voters []uint32 // Voters who cast, only revealed to the creator.
+}
+
+type GetStats struct {
+	Token string                // Token for authentication.
+	ID    skipchain.SkipBlockID // ID of the election skipchain.
+}
+
+type GetStatsReply struct {
+	Stats *chains.Stats // Stats of the election skipchain.
+}
+
+const (
+	// Job states.
+	JOB_RUNNING = iota
+	JOB_DONE
+	JOB_FAILED
+)
+
+// Job reports the progress of a protocol running in the background.
+type Job struct {
+	ID       string                // ID of the job.
+	Kind     string                // Kind is the protocol run by the job.
+	Election skipchain.SkipBlockID // Election is the ID of the election skipchain.
+	Status   uint32                // Status of the job.
+	Node     string                // Node is the conode currently working.
+	Mixes    uint32                // Mixes is the number of stored mixes.
+	Partials uint32                // Partials is the number of stored partials.
+	Key      kyber.Point           // Key assigned by the DKG once done.
+	Error    string                // Error describes why the job failed.
+	Start    int64                 // Start in unix nanoseconds.
+	End      int64                 // End in unix nanoseconds, 0 while running.
+	Deadline int64                 // Deadline in unix nanoseconds.
+}
+
+type GetJob struct {
+	Token string // Token for authentication.
+	ID    string // ID of the job.
+}
+
+type GetJobReply struct {
+	Job *Job // Job with its current progress.
+}
+
 type GetMixes struct {
 	Token string                // Token for authentication.
 	ID    skipchain.SkipBlockID // ID of the election skipchain.
@@ -137,7 +226,35 @@ type Reconstruct struct {
 }
 
 type ReconstructReply struct {
-	Points []kyber.Point // Points are the decrypted plaintexts.
+	Points   []kyber.Point // Points are the decrypted plaintexts.
+	Rejected []string      // Rejected are the nodes with invalid partials.
+}
+
+type Tally struct {
+	Token string                // Token for authentication.
+	ID    skipchain.SkipBlockID // ID of the election skipchain.
+}
+
+type TallyReply struct {
+	Results []*chains.Result // Results of the counting method for each contest.
+}
+
+type GetResult struct {
+	Token string                // Token for authentication.
+	ID    skipchain.SkipBlockID // ID of the election skipchain.
+}
+
+type GetResultReply struct {
+	Results []*chains.Result // Results stored on the election skipchain.
+}
+
+type Export struct {
+	Token string                // Token for authentication.
+	ID    skipchain.SkipBlockID // ID of the election skipchain.
+}
+
+type ExportReply struct {
+	Files []*export.File // Files of the exported bundle.
 }
 
 type Ping struct {
@@ -0,0 +1,233 @@
+package blobs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"sync"
+
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/log"
+	"github.com/dedis/onet/network"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+// Name is the identifier of the service.
+const Name = "blobs"
+
+const (
+	// MaxSize is the largest blob a node accepts, enough for the mix of a
+	// full box with its proofs.
+	MaxSize = 64 << 20
+	// Quota is the total size of the blobs a node stores for an election.
+	Quota = 4 << 30
+)
+
+var (
+	ERR_NOT_FOUND       = errors.New("Blob not found")
+	ERR_INVALID_HASH    = errors.New("Blob does not match its hash")
+	ERR_NOT_REPLICATED  = errors.New("Blob not replicated on a majority of the roster")
+	ERR_TOO_LARGE       = errors.New("Blob exceeds the maximum size")
+	ERR_QUOTA           = errors.New("Blob store quota exceeded")
+	ERR_UNAUTHENTICATED = errors.New("Blob is not signed by a conode of its election")
+)
+
+// Authenticator checks that a blob belongs to an election and is signed by a
+// conode of its roster. The given roster only holds the storing node so that
+// the election is looked up locally.
+type Authenticator func(node *onet.Roster, election, data []byte) error
+
+// authenticate is the check every blob passes before it is stored. Blobs are
+// refused as long as none is registered.
+var authenticate Authenticator
+
+// Authenticate registers the check of the blobs put to a node.
+func Authenticate(check Authenticator) {
+	authenticate = check
+}
+
+// Put stores a blob of an election on a node.
+type Put struct {
+	Election []byte // Election is the ID of the election skipchain.
+	Data     []byte // Data is the content of the blob.
+}
+
+// PutReply is returned upon successfully storing a blob.
+type PutReply struct {
+	Hash []byte // Hash is the content address of the blob.
+}
+
+// Get retrieves a blob from a node.
+type Get struct {
+	Hash []byte // Hash is the content address of the blob.
+}
+
+// GetReply contains the content of a blob.
+type GetReply struct {
+	Data []byte // Data is the content of the blob.
+}
+
+// serviceID is the onet identifier.
+var serviceID onet.ServiceID
+
+// Service keeps the blobs of a node in its database indexed by their hash.
+type Service struct {
+	*onet.ServiceProcessor
+
+	mutex sync.Mutex // mutex guards the database.
+
+	limit int   // limit is the maximum size of a blob.
+	quota int64 // quota is the maximum total size of the blobs of an election.
+}
+
+// blob is the persisted content of a blob.
+type blob struct {
+	Data []byte
+}
+
+// usage is the persisted total size of the stored blobs of an election.
+type usage struct {
+	Size int64
+}
+
+func init() {
+	network.RegisterMessages(Put{}, PutReply{}, Get{}, GetReply{}, blob{}, usage{})
+	serviceID, _ = onet.RegisterNewService(Name, new)
+}
+
+// Put message handler. Stores a blob under its hash unless it is too large,
+// not signed by a conode of its election or the quota of the election on this
+// node is exhausted.
+func (s *Service) Put(req *Put) (*PutReply, error) {
+	if len(req.Data) > s.limit {
+		return nil, ERR_TOO_LARGE
+	}
+
+	node := onet.NewRoster([]*network.ServerIdentity{s.ServerIdentity()})
+	if authenticate == nil || authenticate(node, req.Election, req.Data) != nil {
+		return nil, ERR_UNAUTHENTICATED
+	}
+	hash := Hash(req.Data)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	used := s.spent(req.Election)
+	if _, err := s.load(hash); err == nil {
+		return &PutReply{Hash: hash}, nil
+	} else if used.Size+int64(len(req.Data)) > s.quota {
+		return nil, ERR_QUOTA
+	}
+
+	if err := s.Save(hash, &blob{Data: req.Data}); err != nil {
+		return nil, err
+	}
+	used.Size += int64(len(req.Data))
+	if err := s.Save(account(req.Election), used); err != nil {
+		return nil, err
+	}
+	return &PutReply{Hash: hash}, nil
+}
+
+// Get message handler. Returns the blob stored under a hash.
+func (s *Service) Get(req *Get) (*GetReply, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := s.load(req.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return &GetReply{Data: data}, nil
+}
+
+// load returns a blob from the database.
+func (s *Service) load(hash []byte) ([]byte, error) {
+	msg, err := s.Load(hash)
+	if err != nil {
+		return nil, err
+	} else if msg == nil {
+		return nil, ERR_NOT_FOUND
+	}
+	b, ok := msg.(*blob)
+	if !ok {
+		return nil, ERR_NOT_FOUND
+	}
+	return b.Data, nil
+}
+
+// spent returns the usage of an election.
+func (s *Service) spent(election []byte) *usage {
+	msg, err := s.Load(account(election))
+	if u, ok := msg.(*usage); err == nil && ok {
+		return u
+	}
+	return &usage{}
+}
+
+// account returns the database key of the usage of an election.
+func account(election []byte) []byte {
+	return append([]byte(Name+"/"), election...)
+}
+
+// Hash returns the content address of a blob.
+func Hash(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// Store replicates a blob of an election on every node of the roster and
+// returns its hash. Unreachable nodes are skipped as long as a majority holds
+// the blob.
+func Store(roster *onet.Roster, election, data []byte) ([]byte, error) {
+	client := onet.NewClient(crypto.Suite, Name)
+
+	hash, stored := Hash(data), 0
+	for _, node := range roster.List {
+		reply := &PutReply{}
+		if err := client.SendProtobuf(node, &Put{Election: election, Data: data}, reply); err != nil {
+			log.Lvl2("failed to store blob on", node, err)
+			continue
+		} else if !bytes.Equal(hash, reply.Hash) {
+			log.Lvl2("invalid blob hash from", node)
+			continue
+		}
+		stored++
+	}
+
+	if 2*stored <= len(roster.List) {
+		return nil, ERR_NOT_REPLICATED
+	}
+	return hash, nil
+}
+
+// Retrieve fetches a blob from the first node of the roster that holds it and
+// checks the content against its hash.
+func Retrieve(roster *onet.Roster, hash []byte) ([]byte, error) {
+	client := onet.NewClient(crypto.Suite, Name)
+
+	err := ERR_NOT_FOUND
+	for _, node := range roster.List {
+		reply := &GetReply{}
+		if err = client.SendProtobuf(node, &Get{Hash: hash}, reply); err != nil {
+			continue
+		} else if !bytes.Equal(hash, Hash(reply.Data)) {
+			err = ERR_INVALID_HASH
+			continue
+		}
+		return reply.Data, nil
+	}
+	return nil, err
+}
+
+// new initializes the service and registers the handlers.
+func new(context *onet.Context) (onet.Service, error) {
+	service := &Service{
+		ServiceProcessor: onet.NewServiceProcessor(context),
+		limit:            MaxSize,
+		quota:            Quota,
+	}
+	service.RegisterHandlers(service.Put, service.Get)
+	return service, nil
+}
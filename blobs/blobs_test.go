@@ -0,0 +1,135 @@
+package blobs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dedis/onet"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+func init() {
+	Authenticate(vouch)
+}
+
+// vouch accepts every blob of an election with a non-empty ID.
+func vouch(node *onet.Roster, election, data []byte) error {
+	if len(election) == 0 {
+		return errors.New("unknown election")
+	}
+	return nil
+}
+
+func TestPut(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+
+	r, _ := s.Put(&Put{Election: []byte{1}, Data: []byte{1, 2, 3}})
+	assert.Equal(t, Hash([]byte{1, 2, 3}), r.Hash)
+}
+
+func TestPut_Unauthenticated(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+
+	_, err := s.Put(&Put{Data: []byte{1, 2, 3}})
+	assert.Equal(t, ERR_UNAUTHENTICATED, err)
+
+	_, err = s.Get(&Get{Hash: Hash([]byte{1, 2, 3})})
+	assert.Equal(t, ERR_NOT_FOUND, err)
+}
+
+func TestGet(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+
+	_, err := s.Get(&Get{Hash: Hash([]byte{1})})
+	assert.Equal(t, ERR_NOT_FOUND, err)
+
+	_, _ = s.Put(&Put{Election: []byte{1}, Data: []byte{1}})
+	r, _ := s.Get(&Get{Hash: Hash([]byte{1})})
+	assert.Equal(t, []byte{1}, r.Data)
+}
+
+func TestPut_Limits(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.limit, s.quota = 4, 6
+
+	_, err := s.Put(&Put{Election: []byte{1}, Data: []byte{1, 2, 3, 4, 5}})
+	assert.Equal(t, ERR_TOO_LARGE, err)
+
+	_, err = s.Put(&Put{Election: []byte{1}, Data: []byte{1, 2, 3, 4}})
+	assert.Nil(t, err)
+	_, err = s.Put(&Put{Election: []byte{1}, Data: []byte{1, 2, 3, 4}})
+	assert.Nil(t, err)
+	_, err = s.Put(&Put{Election: []byte{1}, Data: []byte{1, 2, 3}})
+	assert.Equal(t, ERR_QUOTA, err)
+	assert.Equal(t, int64(4), s.spent([]byte{1}).Size)
+
+	_, err = s.Put(&Put{Election: []byte{2}, Data: []byte{1, 2, 3}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), s.spent([]byte{2}).Size)
+}
+
+func TestGet_Restart(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	_, _ = s.Put(&Put{Election: []byte{1}, Data: []byte{1, 2}})
+
+	restarted, err := new(s.Context)
+	assert.Nil(t, err)
+	r, _ := restarted.(*Service).Get(&Get{Hash: Hash([]byte{1, 2})})
+	assert.Equal(t, []byte{1, 2}, r.Data)
+	assert.Equal(t, int64(2), restarted.(*Service).spent([]byte{1}).Size)
+}
+
+func TestStore(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	hash, _ := Store(roster, []byte{1}, []byte{1, 2})
+	for _, service := range local.GetServices(nodes, serviceID) {
+		r, _ := service.(*Service).Get(&Get{Hash: hash})
+		assert.Equal(t, []byte{1, 2}, r.Data)
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	services := local.GetServices(nodes, serviceID)
+
+	_, err := Retrieve(roster, Hash([]byte{1}))
+	assert.NotNil(t, err)
+
+	_, _ = services[2].(*Service).Put(&Put{Election: []byte{1}, Data: []byte{1}})
+	data, _ := Retrieve(roster, Hash([]byte{1}))
+	assert.Equal(t, []byte{1}, data)
+
+	_ = services[0].(*Service).Save(Hash([]byte{2}), &blob{Data: []byte{3}})
+	_, err = Retrieve(roster, Hash([]byte{2}))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,121 @@
+package chains
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/dedis/cothority/skipchain"
+	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/sign/schnorr"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/network"
+
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/dkg"
+)
+
+var ERR_UNENDORSED = errors.New("Roster change is not endorsed by enough nodes of the roster")
+
+// RosterChange is appended to the election skipchain once the shared secret
+// has been reshared to a new roster while the election is running. The key
+// of the election is unchanged, but mixes and partials are then expected
+// from the new roster. It only takes effect if it is endorsed by at least
+// the threshold of the previous roster.
+type RosterChange struct {
+	Roster    *onet.Roster  // Roster is the new set of responsible nodes.
+	Threshold uint32        // Threshold is the number of partials needed to decrypt.
+	Commits   []kyber.Point // Commits are the public coefficients of the new shares.
+	Shares    []kyber.Point // Shares are the public shares of the new roster.
+
+	Signers    []kyber.Point // Signers are the endorsing nodes of the previous roster.
+	Signatures [][]byte      // Signatures of the change digest by the signers.
+}
+
+func init() {
+	network.RegisterMessage(RosterChange{})
+}
+
+// NewRosterChange creates the roster change committing to a reshared secret.
+func NewRosterChange(roster *onet.Roster, secret *dkg.SharedSecret) *RosterChange {
+	return &RosterChange{
+		Roster:    roster,
+		Threshold: uint32(len(secret.Commits)),
+		Commits:   secret.Commits,
+		Shares:    secret.PublicShares(len(roster.List)),
+	}
+}
+
+// Digest hashes the election ID together with the new roster, the threshold
+// and the public shares of a roster change.
+func (c *RosterChange) Digest(id skipchain.SkipBlockID) []byte {
+	hash := sha256.New()
+	hash.Write(id)
+	if c.Roster != nil {
+		for _, node := range c.Roster.List {
+			digestPoint(hash, node.Public)
+			hash.Write([]byte(node.Address))
+		}
+	}
+	binary.Write(hash, binary.BigEndian, c.Threshold)
+	for _, commit := range c.Commits {
+		digestPoint(hash, commit)
+	}
+	for _, public := range c.Shares {
+		digestPoint(hash, public)
+	}
+	return hash.Sum(nil)
+}
+
+// Sign returns a Schnorr signature of the change digest with the private key
+// of a server identity.
+func (c *RosterChange) Sign(id skipchain.SkipBlockID, secret kyber.Scalar) ([]byte, error) {
+	return schnorr.Sign(crypto.Suite, secret, c.Digest(id))
+}
+
+// Endorse checks the signature of a node and adds it to the change.
+func (c *RosterChange) Endorse(id skipchain.SkipBlockID, public kyber.Point, sig []byte) error {
+	if err := schnorr.Verify(crypto.Suite, public, c.Digest(id), sig); err != nil {
+		return err
+	}
+	c.Signers = append(c.Signers, public)
+	c.Signatures = append(c.Signatures, sig)
+	return nil
+}
+
+// Verify checks that the change keeps the key of an election and that it is
+// endorsed by at least the threshold of the current roster of the election.
+func (c *RosterChange) Verify(e *Election) error {
+	if c.Roster == nil || len(c.Commits) == 0 || !c.Commits[0].Equal(e.Key) {
+		return ERR_INVALID_TRANSCRIPT
+	} else if err := e.Reshared(c).VerifyTranscript(); err != nil {
+		return err
+	} else if len(c.Signers) != len(c.Signatures) {
+		return ERR_UNENDORSED
+	}
+
+	digest, endorsed := c.Digest(e.ID), make(map[int]bool)
+	for i, signer := range c.Signers {
+		if signer == nil || schnorr.Verify(crypto.Suite, signer, digest, c.Signatures[i]) != nil {
+			continue
+		}
+		for j, node := range e.Roster.List {
+			if node.Public.Equal(signer) {
+				endorsed[j] = true
+			}
+		}
+	}
+	if len(endorsed) < e.RequiredPartials() {
+		return ERR_UNENDORSED
+	}
+	return nil
+}
+
+// Reshared returns a copy of the election with the roster, the threshold and
+// the DKG transcript of a roster change.
+func (e *Election) Reshared(c *RosterChange) *Election {
+	changed := *e
+	changed.Roster, changed.Threshold = c.Roster, c.Threshold
+	changed.Commits, changed.Shares = c.Commits, c.Shares
+	return &changed
+}
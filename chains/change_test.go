@@ -0,0 +1,61 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/dedis/kyber"
+	"github.com/dedis/onet"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/dkg"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+// reshared returns a roster change keeping the key of an election, endorsed
+// by the given private keys.
+func reshared(e *Election, roster *onet.Roster, keys []kyber.Scalar) *RosterChange {
+	commits := []kyber.Point{e.Key, crypto.Suite.Point().Pick(crypto.Suite.RandomStream())}
+	change := NewRosterChange(roster, &dkg.SharedSecret{Commits: commits})
+	for _, key := range keys {
+		sig, _ := change.Sign(e.ID, key)
+		_ = change.Endorse(e.ID, crypto.Suite.Point().Mul(key, nil), sig)
+	}
+	return change
+}
+
+func TestRosterChange_Verify(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+	keys := testutil.Keys(local, nodes)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(3)
+	sub := onet.NewRoster(roster.List[:3])
+
+	change := reshared(election, sub, keys[:3])
+	assert.Nil(t, change.Verify(election))
+	assert.Nil(t, election.Reshared(change).VerifyTranscript())
+
+	change = reshared(election, sub, []kyber.Scalar{keys[0], keys[1], keys[1]})
+	assert.Equal(t, ERR_UNENDORSED, change.Verify(election))
+
+	x, _ := crypto.RandomKeyPair()
+	change = reshared(election, sub, []kyber.Scalar{keys[0], keys[1], x})
+	assert.Equal(t, ERR_UNENDORSED, change.Verify(election))
+
+	change = reshared(election, sub, keys[:3])
+	change.Threshold = 3
+	assert.Equal(t, ERR_INVALID_TRANSCRIPT, change.Verify(election))
+
+	_, X := crypto.RandomKeyPair()
+	change = reshared(&Election{ID: election.ID, Key: X}, sub, keys[:3])
+	assert.Equal(t, ERR_INVALID_TRANSCRIPT, change.Verify(election))
+
+	change = reshared(election, sub, keys[:3])
+	sig, _ := change.Sign(election.ID, keys[3])
+	assert.NotNil(t, change.Endorse(election.ID, crypto.Suite.Point().Mul(keys[0], nil), sig))
+}
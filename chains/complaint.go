@@ -0,0 +1,75 @@
+package chains
+
+import (
+	"encoding/binary"
+
+	"github.com/dedis/cothority/skipchain"
+	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/sign/schnorr"
+	"github.com/dedis/onet/network"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+// Complaint is appended to the election skipchain by a shuffler that could not
+// verify the box or the mix of its predecessor. It stops the chain of shuffles
+// and marks the election as corrupt.
+type Complaint struct {
+	Node   string      // Node is the name of the complaining conode.
+	Public kyber.Point // Public key of the complaining conode.
+	Mix    int         // Mix is the index of the rejected mix, -1 for the box.
+	Reason string      // Reason why the verification failed.
+
+	Signature []byte // Signature of the complaint digest.
+}
+
+func init() {
+	network.RegisterMessage(Complaint{})
+}
+
+// Digest binds a complaint to an election by appending the index of the
+// rejected mix and the reason to the election ID.
+func (c *Complaint) Digest(id skipchain.SkipBlockID) []byte {
+	mix := make([]byte, 4)
+	binary.BigEndian.PutUint32(mix, uint32(c.Mix))
+	return append(append(append([]byte{}, id...), mix...), c.Reason...)
+}
+
+// Sign creates a Schnorr signature of the complaint digest.
+func (c *Complaint) Sign(id skipchain.SkipBlockID, secret kyber.Scalar) error {
+	sig, err := schnorr.Sign(crypto.Suite, secret, c.Digest(id))
+	c.Signature = sig
+	return err
+}
+
+// Verify checks the Schnorr signature against the public key of the complaint.
+func (c *Complaint) Verify(id skipchain.SkipBlockID) error {
+	return schnorr.Verify(crypto.Suite, c.Public, c.Digest(id), c.Signature)
+}
+
+// Complaints returns all authentic complaints raised during the shuffle.
+// Complaints with an invalid signature or from outside the roster are ignored.
+func (e *Election) Complaints() ([]*Complaint, error) {
+	chain, err := chain(e.Roster, e.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	complaints := make([]*Complaint, 0)
+	for _, block := range chain {
+		_, blob, _ := network.Unmarshal(block.Data, crypto.Suite)
+		if complaint, ok := blob.(*Complaint); ok && e.authentic(complaint) {
+			complaints = append(complaints, complaint)
+		}
+	}
+	return complaints, nil
+}
+
+// authentic checks the signature of a complaint and that its author belongs
+// to the roster.
+func (e *Election) authentic(complaint *Complaint) bool {
+	if complaint.Public == nil || complaint.Verify(e.ID) != nil {
+		return false
+	}
+	return e.member(complaint.Public)
+}
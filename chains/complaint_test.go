@@ -0,0 +1,74 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/dedis/onet"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestComplaint_Verify(t *testing.T) {
+	x, X := crypto.RandomKeyPair()
+
+	complaint := &Complaint{Node: "node", Public: X, Mix: 1, Reason: "reason"}
+	_ = complaint.Sign([]byte{1}, x)
+	assert.Nil(t, complaint.Verify([]byte{1}))
+	assert.NotNil(t, complaint.Verify([]byte{2}))
+
+	complaint.Mix = 2
+	assert.NotNil(t, complaint.Verify([]byte{1}))
+}
+
+func TestComplaints(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	keys := testutil.Keys(local, nodes)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(3)
+
+	public := roster.List[0].Public
+	complaint := &Complaint{Node: "node", Public: public, Mix: -1, Reason: "reason"}
+	_ = complaint.Sign(election.ID, keys[0])
+	_ = election.Store(complaint)
+
+	complaints, _ := election.Complaints()
+	assert.Equal(t, 1, len(complaints))
+	assert.Nil(t, complaints[0].Verify(election.ID))
+
+	e, _ := FetchElection(roster, election.ID)
+	assert.Equal(t, CORRUPT, int(e.Stage))
+}
+
+func TestComplaints_Forged(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	keys := testutil.Keys(local, nodes)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(3)
+
+	x, X := crypto.RandomKeyPair()
+	outsider := &Complaint{Node: "node", Public: X, Mix: -1, Reason: "reason"}
+	_ = outsider.Sign(election.ID, x)
+	_ = election.Store(outsider)
+
+	public := roster.List[0].Public
+	forged := &Complaint{Node: "node", Public: public, Mix: -1, Reason: "reason"}
+	_ = forged.Sign(election.ID, keys[1])
+	_ = election.Store(forged)
+
+	complaints, _ := election.Complaints()
+	assert.Equal(t, 0, len(complaints))
+
+	e, _ := FetchElection(roster, election.ID)
+	assert.Equal(t, RUNNING, int(e.Stage))
+}
@@ -1,13 +1,20 @@
 package chains
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/dedis/cothority/skipchain"
 	"github.com/dedis/kyber"
-	"github.com/dedis/kyber/proof"
-	neff "github.com/dedis/kyber/shuffle"
+	"github.com/dedis/kyber/proof/dleq"
+	"github.com/dedis/kyber/sign/schnorr"
 	"github.com/qantik/nevv/crypto"
 	"github.com/qantik/nevv/dkg"
 
-	rabin "github.com/dedis/kyber/share/dkg/rabin"
+	pedersen "github.com/dedis/kyber/share/dkg/pedersen"
 )
 
 // Ballot represents an encrypted vote.
@@ -17,6 +24,18 @@ type Ballot struct {
 	// ElGamal ciphertext pair.
 	Alpha kyber.Point
 	Beta  kyber.Point
+
+	Proof   []byte // Proof of knowledge of the ephemeral key Alpha.
+	Weight  uint32 // Weight of the ballot, assigned from the voter roll.
+	Contest uint32 // Contest is the index of the race the ballot is cast in.
+}
+
+// Digest binds a ballot to an election by appending the user identifier to
+// the election ID. It serves as context for the ballot proof.
+func (b *Ballot) Digest(id skipchain.SkipBlockID) []byte {
+	user := make([]byte, 4)
+	binary.BigEndian.PutUint32(user, b.User)
+	return append(append([]byte{}, id...), user...)
 }
 
 // Box is a wrapper around a list of encrypted ballots.
@@ -25,46 +44,295 @@ type Box struct {
 }
 
 // genMix generates n mixes with corresponding proofs out of the ballots.
-func (b *Box) genMix(key kyber.Point, n int) []*Mix {
+func (b *Box) genMix(prover crypto.ShuffleProver, key kyber.Point, n int) []*Mix {
 	mixes := make([]*Mix, n)
 
-	x, y := Split(b.Ballots)
+	ballots := b.Ballots
 	for i := range mixes {
-		v, w, prover := neff.Shuffle(crypto.Suite, nil, key, x, y, crypto.Stream)
-		proof, _ := proof.HashProve(crypto.Suite, "", prover)
-		mixes[i] = &Mix{Ballots: Combine(v, w), Proof: proof, Node: string(i)}
-		x, y = v, w
+		mixes[i], _ = Shuffle(prover, key, ballots, nil)
+		mixes[i].Node = string(i)
+		ballots = mixes[i].Ballots
 	}
 	return mixes
 }
 
-// Mix contains the shuffled ballots.
+var (
+	ERR_INVALID_SHUFFLE = errors.New("Mix does not match the shuffle proofs")
+	ERR_UNSIGNED        = errors.New("Contribution is not signed")
+	ERR_INCOMPLETE      = errors.New("Contribution has missing points or proofs")
+)
+
+// Mix contains the shuffled ballots. The ballots are grouped in classes of
+// the same contest and weight, ordered by contest and then weight, and each
+// class is shuffled separately.
 type Mix struct {
 	Ballots []*Ballot // Ballots are permuted and re-encrypted.
-	Proof   []byte    // Proof of the shuffle.
+	Proofs  [][]byte  // Proofs of the shuffle of each class.
+
+	Node     string // Node signifies the creator of the mix.
+	Start    int64  // Start of the shuffle in unix nanoseconds.
+	Duration int64  // Duration of the shuffle in nanoseconds.
+
+	Signer    kyber.Point // Signer is the server identity key of the creator.
+	Signature []byte      // Signature of the mix digest by the creator.
+}
+
+// Digest hashes the election ID together with the shuffled ballots, the
+// proofs and the creator of a mix.
+func (m *Mix) Digest(id skipchain.SkipBlockID) []byte {
+	hash := sha256.New()
+	hash.Write(id)
+	for _, ballot := range m.Ballots {
+		digestPoint(hash, ballot.Alpha)
+		digestPoint(hash, ballot.Beta)
+		binary.Write(hash, binary.BigEndian, []uint32{ballot.Weight, ballot.Contest})
+	}
+	for _, proof := range m.Proofs {
+		hash.Write(proof)
+	}
+	hash.Write([]byte(m.Node))
+	return hash.Sum(nil)
+}
+
+// Sign creates a Schnorr signature of the mix digest with the private key of
+// a server identity.
+func (m *Mix) Sign(id skipchain.SkipBlockID, secret kyber.Scalar) (err error) {
+	m.Signer = crypto.Suite.Point().Mul(secret, nil)
+	m.Signature, err = schnorr.Sign(crypto.Suite, secret, m.Digest(id))
+	return
+}
+
+// Verify checks the signature of the mix against its signer.
+func (m *Mix) Verify(id skipchain.SkipBlockID) error {
+	if m.Signer == nil {
+		return ERR_UNSIGNED
+	}
+	return schnorr.Verify(crypto.Suite, m.Signer, m.Digest(id), m.Signature)
+}
 
-	Node string // Node signifies the creator of the mix.
+// Weights returns the weight of each shuffled ballot.
+func (m *Mix) Weights() []uint32 {
+	weights := make([]uint32, len(m.Ballots))
+	for i, ballot := range m.Ballots {
+		weights[i] = ballot.Weight
+	}
+	return weights
+}
+
+// Segment returns the positions of the shuffled ballots of a contest.
+func (m *Mix) Segment(contest uint32) []int {
+	positions := make([]int, 0)
+	for i, ballot := range m.Ballots {
+		if ballot.Contest == contest {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// Classes partitions a list of ballots into classes of the same contest and
+// weight, ordered by contest and then weight. The order of the ballots within
+// a class is preserved. Elections are only opened if their weights split the
+// voters of each contest into classes of at least MinClass voters, and only
+// shuffled if the cast ballots do so as well.
+func Classes(ballots []*Ballot) [][]*Ballot {
+	type key struct{ contest, weight uint32 }
+
+	indices := make(map[key]int)
+	classes := make([][]*Ballot, 0)
+	for _, ballot := range ballots {
+		i, found := indices[key{ballot.Contest, ballot.Weight}]
+		if !found {
+			i = len(classes)
+			indices[key{ballot.Contest, ballot.Weight}] = i
+			classes = append(classes, make([]*Ballot, 0))
+		}
+		classes[i] = append(classes[i], ballot)
+	}
+
+	sort.SliceStable(classes, func(i, j int) bool {
+		a, b := classes[i][0], classes[j][0]
+		if a.Contest != b.Contest {
+			return a.Contest < b.Contest
+		}
+		return a.Weight < b.Weight
+	})
+	return classes
+}
+
+// Anonymous checks that the cast ballots of each contest with more than one
+// weight class hold at least MinClass ballots per class. Otherwise a class
+// with few eligible voters but even fewer ballots would reveal its votes.
+func (b *Box) Anonymous() bool {
+	classes := Classes(b.Ballots)
+	split := make(map[uint32]int)
+	for _, class := range classes {
+		split[class[0].Contest]++
+	}
+	for _, class := range classes {
+		if split[class[0].Contest] > 1 && len(class) < MinClass {
+			return false
+		}
+	}
+	return true
+}
+
+// Shuffle permutes and re-encrypts each class of a list of ballots and proves
+// the correctness of every class shuffle with a given proof system. The
+// re-encryption factors are drawn from the pool, which may be nil.
+func Shuffle(prover crypto.ShuffleProver, key kyber.Point, ballots []*Ballot,
+	pool *crypto.Pool) (*Mix, error) {
+
+	mix := &Mix{Ballots: make([]*Ballot, 0), Proofs: make([][]byte, 0)}
+	for _, class := range Classes(ballots) {
+		x, y := Split(class)
+		v, w, proof, err := prover.Shuffle(key, x, y, pool.Take(len(class)))
+		if err != nil {
+			return nil, err
+		}
+
+		shuffled := Combine(v, w)
+		for _, ballot := range shuffled {
+			ballot.Weight, ballot.Contest = class[0].Weight, class[0].Contest
+		}
+		mix.Ballots = append(mix.Ballots, shuffled...)
+		mix.Proofs = append(mix.Proofs, proof)
+	}
+	return mix, nil
+}
+
+// VerifyShuffle checks that a mix is a correct shuffle of a list of ballots.
+func VerifyShuffle(prover crypto.ShuffleProver, key kyber.Point, ballots []*Ballot, mix *Mix) error {
+	classes := Classes(ballots)
+	if len(classes) != len(mix.Proofs) || len(ballots) != len(mix.Ballots) {
+		return ERR_INVALID_SHUFFLE
+	}
+
+	offset := 0
+	for i, class := range classes {
+		shuffled := mix.Ballots[offset : offset+len(class)]
+		for _, ballot := range shuffled {
+			if ballot.Weight != class[0].Weight || ballot.Contest != class[0].Contest {
+				return ERR_INVALID_SHUFFLE
+			}
+		}
+
+		x, y := Split(class)
+		v, w := Split(shuffled)
+		if prover.Verify(mix.Proofs[i], key, x, y, v, w) != nil {
+			return ERR_INVALID_SHUFFLE
+		}
+		offset += len(class)
+	}
+	return nil
 }
 
 // Partial contains the partially decrypted ballots.
 type Partial struct {
 	Points []kyber.Point // Points are the partially decrypted plaintexts.
+	Proofs []*dleq.Proof // Proofs of correct decryption for each point.
+	Index  int           // Index of the creator's DKG share.
+	Public kyber.Point   // Public is the public share of the creator.
 
-	Flag bool   // Flag signals if the mixes could not be verified.
-	Node string // Node signifies the creator of this partial decryption.
+	Flag     bool   // Flag signals if the mixes could not be verified.
+	Node     string // Node signifies the creator of this partial decryption.
+	Start    int64  // Start of the decryption in unix nanoseconds.
+	Duration int64  // Duration of the decryption in nanoseconds.
+
+	Signer    kyber.Point // Signer is the server identity key of the creator.
+	Signature []byte      // Signature of the partial digest by the creator.
+}
+
+// Digest hashes the election ID together with the points, the proofs, the
+// share and the creator of a partial decryption.
+func (p *Partial) Digest(id skipchain.SkipBlockID) []byte {
+	hash := sha256.New()
+	hash.Write(id)
+	for _, point := range p.Points {
+		digestPoint(hash, point)
+	}
+	for _, proof := range p.Proofs {
+		if proof == nil {
+			continue
+		}
+		digestScalar(hash, proof.C)
+		digestScalar(hash, proof.R)
+		digestPoint(hash, proof.VG)
+		digestPoint(hash, proof.VH)
+	}
+	binary.Write(hash, binary.BigEndian, int64(p.Index))
+	digestPoint(hash, p.Public)
+	binary.Write(hash, binary.BigEndian, p.Flag)
+	hash.Write([]byte(p.Node))
+	return hash.Sum(nil)
+}
+
+// Sign creates a Schnorr signature of the partial digest with the private
+// key of a server identity.
+func (p *Partial) Sign(id skipchain.SkipBlockID, secret kyber.Scalar) (err error) {
+	p.Signer = crypto.Suite.Point().Mul(secret, nil)
+	p.Signature, err = schnorr.Sign(crypto.Suite, secret, p.Digest(id))
+	return
+}
+
+// Verify checks that the partial is complete and its signature against its
+// signer.
+func (p *Partial) Verify(id skipchain.SkipBlockID) error {
+	if p.Signer == nil {
+		return ERR_UNSIGNED
+	} else if err := p.Complete(); err != nil {
+		return err
+	}
+	return schnorr.Verify(crypto.Suite, p.Signer, p.Digest(id), p.Signature)
+}
+
+// Complete checks that every point of the partial has a proof and that none
+// of them is missing.
+func (p *Partial) Complete() error {
+	if len(p.Points) != len(p.Proofs) {
+		return ERR_INCOMPLETE
+	}
+	for i, proof := range p.Proofs {
+		if p.Points[i] == nil || proof == nil || proof.C == nil || proof.R == nil ||
+			proof.VG == nil || proof.VH == nil {
+			return ERR_INCOMPLETE
+		}
+	}
+	return nil
+}
+
+// digestPoint writes a point to a hash, skipping missing points.
+func digestPoint(hash io.Writer, point kyber.Point) {
+	if point != nil {
+		point.MarshalTo(hash)
+	}
+}
+
+// digestScalar writes a scalar to a hash, skipping missing scalars.
+func digestScalar(hash io.Writer, scalar kyber.Scalar) {
+	if scalar != nil {
+		scalar.MarshalTo(hash)
+	}
 }
 
 // genPartials generates partial decryptions for a given list of shared secrets.
-func (m *Mix) genPartials(dkgs []*rabin.DistKeyGenerator) []*Partial {
+func (m *Mix) genPartials(dkgs []*pedersen.DistKeyGenerator) []*Partial {
 	partials := make([]*Partial, len(dkgs))
 
 	for i, gen := range dkgs {
 		secret, _ := dkg.NewSharedSecret(gen)
 		points := make([]kyber.Point, len(m.Ballots))
+		proofs := make([]*dleq.Proof, len(m.Ballots))
 		for j, ballot := range m.Ballots {
-			points[j] = crypto.Decrypt(secret.V, ballot.Alpha, ballot.Beta)
+			points[j], proofs[j], _ = crypto.ProveDecryption(secret.V, ballot.Alpha, ballot.Beta)
+		}
+		partials[i] = &Partial{
+			Points: points,
+			Proofs: proofs,
+			Index:  secret.Index,
+			Public: secret.PublicShare(secret.Index),
+			Node:   string(i),
 		}
-		partials[i] = &Partial{Points: points, Node: string(i)}
 	}
 	return partials
 }
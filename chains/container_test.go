@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/proof/dleq"
 
 	"github.com/stretchr/testify/assert"
 
@@ -33,3 +34,131 @@ func TestCombine(t *testing.T) {
 	assert.Equal(t, X2, ballots[0].Beta)
 	assert.Equal(t, X2, ballots[1].Beta)
 }
+
+func TestClasses(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	ballots := genBox(X, 5).Ballots
+	ballots[0].Weight, ballots[1].Weight, ballots[2].Weight = 3, 1, 3
+	ballots[3].Weight, ballots[4].Weight = 1, 1
+
+	classes := Classes(ballots)
+	assert.Equal(t, 2, len(classes))
+	assert.Equal(t, []*Ballot{ballots[1], ballots[3], ballots[4]}, classes[0])
+	assert.Equal(t, []*Ballot{ballots[0], ballots[2]}, classes[1])
+}
+
+func TestClasses_Contests(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	ballots := genBox(X, 4).Ballots
+	ballots[0].Contest, ballots[0].Weight = 1, 1
+	ballots[1].Contest, ballots[1].Weight = 0, 2
+	ballots[2].Contest, ballots[2].Weight = 1, 1
+	ballots[3].Contest, ballots[3].Weight = 0, 1
+
+	classes := Classes(ballots)
+	assert.Equal(t, [][]*Ballot{
+		[]*Ballot{ballots[3]}, []*Ballot{ballots[1]}, []*Ballot{ballots[0], ballots[2]},
+	}, classes)
+}
+
+func TestBox_Anonymous(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	box := genBox(X, 4)
+	assert.True(t, box.Anonymous())
+
+	box.Ballots[3].Weight = 2
+	assert.False(t, box.Anonymous())
+
+	box.Ballots[3].Contest = 1
+	assert.True(t, box.Anonymous())
+
+	box = genBox(X, 6)
+	box.Ballots[3].Weight, box.Ballots[4].Weight, box.Ballots[5].Weight = 2, 2, 2
+	assert.True(t, box.Anonymous())
+}
+
+func TestShuffle(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	ballots := genBox(X, 5).Ballots
+	ballots[0].Weight, ballots[2].Weight = 2, 2
+
+	mix, _ := Shuffle(crypto.Neff{}, X, ballots, nil)
+	assert.Equal(t, 2, len(mix.Proofs))
+	assert.Equal(t, []uint32{0, 0, 0, 2, 2}, mix.Weights())
+	assert.Nil(t, VerifyShuffle(crypto.Neff{}, X, ballots, mix))
+
+	mix.Ballots[0].Weight = 2
+	assert.Equal(t, ERR_INVALID_SHUFFLE, VerifyShuffle(crypto.Neff{}, X, ballots, mix))
+
+	mix.Ballots[0].Weight = 0
+	mix.Ballots[0], mix.Ballots[3] = mix.Ballots[3], mix.Ballots[0]
+	assert.Equal(t, ERR_INVALID_SHUFFLE, VerifyShuffle(crypto.Neff{}, X, ballots, mix))
+}
+
+func TestShuffle_Pool(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	ballots := genBox(X, 5).Ballots
+
+	pool := crypto.NewPool(X)
+	pool.Fill(7)
+	mix, _ := Shuffle(crypto.Neff{}, X, ballots, pool)
+	assert.Nil(t, VerifyShuffle(crypto.Neff{}, X, ballots, mix))
+	assert.Equal(t, 2, pool.Size())
+}
+
+func TestShuffle_Wikstrom(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	ballots := genBox(X, 5).Ballots
+
+	mix, _ := Shuffle(crypto.Wikstrom{}, X, ballots, nil)
+	assert.Nil(t, VerifyShuffle(crypto.Wikstrom{}, X, ballots, mix))
+	assert.Equal(t, ERR_INVALID_SHUFFLE, VerifyShuffle(crypto.Neff{}, X, ballots, mix))
+}
+
+func TestSegment(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	ballots := genBox(X, 4).Ballots
+	ballots[0].Contest, ballots[2].Contest = 1, 1
+
+	mix, _ := Shuffle(crypto.Neff{}, X, ballots, nil)
+	assert.Equal(t, []int{0, 1}, mix.Segment(0))
+	assert.Equal(t, []int{2, 3}, mix.Segment(1))
+	assert.Equal(t, []int{}, mix.Segment(2))
+}
+
+func TestMix_Verify(t *testing.T) {
+	x, X := crypto.RandomKeyPair()
+	mix, _ := Shuffle(crypto.Neff{}, X, genBox(X, 3).Ballots, nil)
+
+	assert.Equal(t, ERR_UNSIGNED, mix.Verify([]byte{1}))
+
+	_ = mix.Sign([]byte{1}, x)
+	assert.True(t, X.Equal(mix.Signer))
+	assert.Nil(t, mix.Verify([]byte{1}))
+	assert.NotNil(t, mix.Verify([]byte{2}))
+
+	mix.Ballots[0], mix.Ballots[1] = mix.Ballots[1], mix.Ballots[0]
+	assert.NotNil(t, mix.Verify([]byte{1}))
+}
+
+func TestPartial_Verify(t *testing.T) {
+	x, X := crypto.RandomKeyPair()
+	partial := &Partial{Index: 1, Public: X}
+
+	assert.Equal(t, ERR_UNSIGNED, partial.Verify([]byte{1}))
+
+	_ = partial.Sign([]byte{1}, x)
+	assert.Nil(t, partial.Verify([]byte{1}))
+
+	partial.Flag = true
+	assert.NotNil(t, partial.Verify([]byte{1}))
+
+	partial.Points, partial.Proofs = []kyber.Point{X}, []*dleq.Proof{nil}
+	assert.Equal(t, ERR_INCOMPLETE, partial.Verify([]byte{1}))
+
+	partial.Proofs = []*dleq.Proof{{C: x}}
+	assert.Equal(t, ERR_INCOMPLETE, partial.Verify([]byte{1}))
+
+	partial.Proofs = nil
+	assert.Equal(t, ERR_INCOMPLETE, partial.Verify([]byte{1}))
+}
@@ -0,0 +1,88 @@
+package chains
+
+import (
+	"github.com/dedis/onet/network"
+)
+
+// MinClass is the minimum number of eligible voters of a contest sharing a
+// weight, and of ballots cast in each weight class. The weight classes of a
+// contest are shuffled separately, so a smaller class would single out its
+// voters.
+const MinClass = 3
+
+// Contest is an independent race of an election with its own voters and
+// ballot schema. All contests share the DKG key and the skipchain of their
+// election, but every contest has its own box, shuffle and decryption.
+type Contest struct {
+	Name   string   // Name of the contest.
+	Users  []uint32 // Users is the subset of voters eligible in the contest.
+	Schema *Schema  // Schema defines decoding and counting of the contest.
+}
+
+func init() {
+	network.RegisterMessages(Contest{})
+}
+
+// Races returns the contests of an election. An election without contests
+// consists of a single contest open to all its voters.
+func (e *Election) Races() []*Contest {
+	if len(e.Contests) == 0 {
+		return []*Contest{&Contest{Name: e.Name, Users: e.Users, Schema: e.Schema}}
+	}
+	return e.Contests
+}
+
+// Eligible checks if a user may cast a ballot in the given contest. A contest
+// without voters is open to all voters of the election.
+func (e *Election) Eligible(user, contest uint32) bool {
+	races := e.Races()
+	if int(contest) >= len(races) || !e.IsUser(user) {
+		return false
+	} else if len(races[contest].Users) == 0 {
+		return true
+	}
+
+	for _, u := range races[contest].Users {
+		if u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// Capacity returns the number of ballots in the box if every eligible voter
+// casts a ballot in every contest. It bounds the expected turnout.
+func (e *Election) Capacity() int {
+	capacity := 0
+	for i := range e.Races() {
+		for _, user := range e.Users {
+			if e.Eligible(user, uint32(i)) {
+				capacity++
+			}
+		}
+	}
+	return capacity
+}
+
+// Anonymous checks that the weights of the eligible voters of each contest
+// split them into classes of at least MinClass voters. Contests whose voters
+// all share a weight form a single class and always pass.
+func (e *Election) Anonymous() bool {
+	for i := range e.Races() {
+		classes := make(map[uint32]int)
+		for _, user := range e.Users {
+			if e.Eligible(user, uint32(i)) {
+				classes[e.Weight(user)]++
+			}
+		}
+		if len(classes) < 2 {
+			continue
+		}
+		for _, size := range classes {
+			if size < MinClass {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaces(t *testing.T) {
+	schema := &Schema{Candidates: []string{"a"}}
+	e := &Election{Name: "e", Users: []uint32{0}, Schema: schema}
+	assert.Equal(t, []*Contest{&Contest{Name: "e", Users: []uint32{0}, Schema: schema}}, e.Races())
+
+	e.Contests = []*Contest{&Contest{Name: "a"}, &Contest{Name: "b"}}
+	assert.Equal(t, e.Contests, e.Races())
+}
+
+func TestEligible(t *testing.T) {
+	e := &Election{Users: []uint32{0, 1}}
+	assert.True(t, e.Eligible(0, 0))
+	assert.False(t, e.Eligible(2, 0))
+	assert.False(t, e.Eligible(0, 1))
+
+	e.Contests = []*Contest{&Contest{}, &Contest{Users: []uint32{1, 2}}}
+	assert.True(t, e.Eligible(0, 0))
+	assert.False(t, e.Eligible(0, 1))
+	assert.True(t, e.Eligible(1, 1))
+	assert.False(t, e.Eligible(2, 1))
+}
+
+func TestCapacity(t *testing.T) {
+	e := &Election{Users: []uint32{0, 1, 2}}
+	assert.Equal(t, 3, e.Capacity())
+
+	e.Contests = []*Contest{&Contest{}, &Contest{Users: []uint32{1, 2, 3}}}
+	assert.Equal(t, 5, e.Capacity())
+}
+
+func TestAnonymous(t *testing.T) {
+	e := &Election{Users: []uint32{0, 1, 2, 3}, Weights: []uint32{1, 1, 1, 5}}
+	assert.False(t, e.Anonymous())
+
+	e.Weights = []uint32{5, 5, 5, 5}
+	assert.True(t, e.Anonymous())
+
+	e.Users, e.Weights = []uint32{0, 1, 2, 3, 4, 5}, []uint32{1, 1, 1, 2, 2, 2}
+	assert.True(t, e.Anonymous())
+
+	e.Contests = []*Contest{&Contest{}, &Contest{Users: []uint32{0, 1, 2, 3}}}
+	assert.False(t, e.Anonymous())
+}
@@ -1,9 +1,14 @@
 package chains
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/dedis/cothority/skipchain"
 	"github.com/dedis/kyber"
-	rabin "github.com/dedis/kyber/share/dkg/rabin"
+	"github.com/dedis/kyber/share"
+	pedersen "github.com/dedis/kyber/share/dkg/pedersen"
+	"github.com/dedis/kyber/sign/schnorr"
 	"github.com/dedis/onet"
 	"github.com/dedis/onet/network"
 
@@ -18,6 +23,19 @@ const (
 	DECRYPTED
 	FINISHED
 	CORRUPT
+	VOID
+)
+
+var (
+	ERR_UNKNOWN_SIGNER     = errors.New("Signer is not part of the roster")
+	ERR_INVALID_TRANSCRIPT = errors.New("DKG transcript is inconsistent with the election key")
+)
+
+const (
+	// Re-voting policies.
+	LAST_WINS = iota
+	FIRST_WINS
+	SINGLE_BALLOT
 )
 
 // Election is the base object for a voting procedure. It is stored
@@ -27,18 +45,44 @@ type Election struct {
 	Name    string   // Name of the election.
 	Creator uint32   // Creator is the election responsible.
 	Users   []uint32 // Users is the list of registered voters.
-
-	ID     skipchain.SkipBlockID // ID is the hash of the genesis block.
-	Roster *onet.Roster          // Roster is the set of responsible nodes
-	Key    kyber.Point           // Key is the DKG public key.
-	Stage  uint32                // Stage indicates the phase of the election.
+	Weights []uint32 // Weights of the voters, aligned with Users.
+
+	ID       skipchain.SkipBlockID // ID is the hash of the genesis block.
+	Roster   *onet.Roster          // Roster is the set of responsible nodes
+	Key      kyber.Point           // Key is the DKG public key.
+	Stage    uint32                // Stage indicates the phase of the election.
+	Policy   uint32                // Policy determines which ballot of a user counts.
+	Proofs   bool                  // Proofs requires ballots to carry a proof.
+	Schema   *Schema               // Schema defines decoding and counting of ballots.
+	Quorum   uint32                // Quorum is the minimum turnout in percent of the roll.
+	MinMixes uint32                // MinMixes is the minimum number of mixes, 0 for the threshold.
+	Mixnet   string                // Mixnet is the shuffle proof system, Neff by default.
+
+	// Threshold is the number of partials needed to decrypt, 0 for the
+	// default DKG threshold of the roster.
+	Threshold uint32
+
+	// Commits are the public coefficients of the DKG polynomial, committing
+	// to Key, and Shares the public shares of the roster nodes by share index.
+	Commits []kyber.Point
+	Shares  []kyber.Point
+
+	Contests []*Contest // Contests are the independent races of the election.
 
 	Description string // Description in string format.
 	End         string // End (termination) date.
 }
 
+// Void is appended to the election skipchain if the turnout is below the
+// quorum when the election is closed. Void elections are neither shuffled
+// nor decrypted.
+type Void struct {
+	Turnout uint32 // Turnout is the number of distinct voters at closing.
+	Roll    uint32 // Roll is the number of eligible voters.
+}
+
 func init() {
-	network.RegisterMessages(Election{}, Ballot{}, Box{}, Mix{}, Partial{})
+	network.RegisterMessages(Election{}, Ballot{}, Box{}, Mix{}, Partial{}, Void{})
 }
 
 // FetchElection retrieves the election object from its skipchain and sets its stage.
@@ -51,42 +95,136 @@ func FetchElection(roster *onet.Roster, id skipchain.SkipBlockID) (*Election, er
 	_, blob, _ := network.Unmarshal(chain[1].Data, crypto.Suite)
 	election := blob.(*Election)
 
-	n, num_mixes, num_partials := len(election.Roster.List), 0, 0
+	num_mixes, num_partials, num_results, num_voids, num_complaints := 0, 0, 0, 0, 0
+	forged, signers := false, make(map[string]bool)
 	for _, block := range chain {
 		_, blob, _ := network.Unmarshal(block.Data, crypto.Suite)
+		if change, ok := blob.(*RosterChange); ok {
+			// The roster may only change before the shuffle.
+			forged = forged || num_mixes > 0 || num_partials > 0 || num_results > 0
+			if err := change.Verify(election); err != nil {
+				forged = true
+				continue
+			}
+			election = election.Reshared(change)
+			continue
+		}
+
+		if signer, err := election.authenticate(blob); err != nil {
+			forged = true
+		} else if signer != "" && signers[signer] {
+			forged = true
+		} else if signer != "" {
+			signers[signer] = true
+		}
+
 		if _, ok := blob.(*Mix); ok {
 			num_mixes++
 		} else if _, ok := blob.(*Partial); ok {
 			num_partials++
+		} else if ref, ok := blob.(*Reference); ok && ref.Kind == MIX_BLOB {
+			num_mixes++
+		} else if ref, ok := blob.(*Reference); ok && ref.Kind == PARTIAL_BLOB {
+			num_partials++
+		} else if _, ok := blob.(*Result); ok {
+			num_results++
+		} else if _, ok := blob.(*Void); ok {
+			num_voids++
+		} else if complaint, ok := blob.(*Complaint); ok && election.authentic(complaint) {
+			num_complaints++
 		}
 	}
 
-	if num_mixes == 0 && num_partials == 0 {
+	n, t := len(election.Roster.List), election.RequiredPartials()
+	m := election.RequiredMixes()
+	if num_complaints > 0 || forged {
+		election.Stage = CORRUPT
+	} else if num_voids > 0 {
+		if num_voids == 1 && num_mixes == 0 && num_partials == 0 && num_results == 0 {
+			election.Stage = VOID
+		} else {
+			election.Stage = CORRUPT
+		}
+	} else if num_mixes == 0 && num_partials == 0 && num_results == 0 {
 		election.Stage = RUNNING
-	} else if num_mixes == n && num_partials == 0 {
+	} else if m <= num_mixes && num_mixes <= n && num_partials == 0 && num_results == 0 {
 		election.Stage = SHUFFLED
-	} else if num_mixes == n && num_partials == n {
+	} else if m <= num_mixes && num_mixes <= n && t <= num_partials && num_partials <= n &&
+		num_results == 0 {
 		election.Stage = DECRYPTED
+	} else if m <= num_mixes && num_mixes <= n && t <= num_partials && num_partials <= n &&
+		num_results == len(election.Races()) {
+		election.Stage = FINISHED
 	} else {
 		election.Stage = CORRUPT
 	}
 	return election, nil
 }
 
+// authenticate checks the signature of a mix or a partial, inline or
+// referenced, and that its signer belongs to the roster. It returns a key
+// identifying the kind of contribution and the signer, or an empty key for
+// any other block.
+func (e *Election) authenticate(blob interface{}) (string, error) {
+	var kind uint32
+	var signer kyber.Point
+	var digest, signature []byte
+	switch data := blob.(type) {
+	case *Mix:
+		kind, signer, digest, signature = MIX_BLOB, data.Signer, data.Digest(e.ID), data.Signature
+	case *Partial:
+		if err := data.Complete(); err != nil {
+			return "", err
+		}
+		kind, signer, digest, signature = PARTIAL_BLOB, data.Signer, data.Digest(e.ID), data.Signature
+	case *Reference:
+		kind, signer, digest, signature = data.Kind, data.Signer, data.Digest, data.Signature
+	default:
+		return "", nil
+	}
+
+	if signer == nil {
+		return "", ERR_UNSIGNED
+	} else if err := schnorr.Verify(crypto.Suite, signer, digest, signature); err != nil {
+		return "", err
+	}
+	if !e.member(signer) {
+		return "", ERR_UNKNOWN_SIGNER
+	}
+	return fmt.Sprintf("%d:%s", kind, signer), nil
+}
+
+// member checks if a public key belongs to a conode of the roster.
+func (e *Election) member(public kyber.Point) bool {
+	for _, node := range e.Roster.List {
+		if node.Public.Equal(public) {
+			return true
+		}
+	}
+	return false
+}
+
 // GenChain creates an election skipchain for a specific stage and a given number of ballots.
-func (e *Election) GenChain(numBallots int) []*rabin.DistKeyGenerator {
+// Mixes and partials are signed with the private keys of the roster conodes in roster order.
+func (e *Election) GenChain(numBallots int, keys ...kyber.Scalar) []*pedersen.DistKeyGenerator {
 	chain, _ := New(e.Roster, nil)
 
 	n := len(e.Roster.List)
-	dkgs, _ := dkg.Simulate(n, n-1)
+	dkgs, _ := dkg.Simulate(n, e.RequiredPartials())
 	s, _ := dkg.NewSharedSecret(dkgs[0])
 
 	e.ID = chain.Hash
 	e.Key = s.X
+	e.Commits, e.Shares = s.Commits, s.PublicShares(n)
 
+	prover, _ := e.Prover()
 	box := genBox(s.X, numBallots)
-	mixes := box.genMix(s.X, n)
+	mixes := box.genMix(prover, s.X, n)
 	partials := mixes[n-1].genPartials(dkgs)
+	for i := 0; i < n && i < len(keys); i++ {
+		mixes[i].Sign(e.ID, keys[i])
+		partials[i].Sign(e.ID, keys[i])
+	}
 
 	e.Store(e)
 	e.storeBallots(box.Ballots)
@@ -96,12 +234,30 @@ func (e *Election) GenChain(numBallots int) []*rabin.DistKeyGenerator {
 	} else if e.Stage == DECRYPTED {
 		e.storeMixes(mixes)
 		e.storePartials(partials)
+	} else if e.Stage == FINISHED {
+		e.storeMixes(mixes)
+		e.storePartials(partials)
+		for i := range e.Races() {
+			e.Store(&Result{Contest: uint32(i)})
+		}
+	} else if e.Stage == VOID {
+		e.Store(&Void{Roll: uint32(len(e.Users))})
 	}
 	return dkgs
 }
 
-// Store appends a given structure to the election skipchain.
+// Store appends a given structure to the election skipchain. Mixes and
+// partials are kept in the blob store and only referenced on the skipchain.
 func (e *Election) Store(data interface{}) error {
+	var err error
+	switch data.(type) {
+	case *Mix, *Partial:
+		data, err = e.offload(data)
+	}
+	if err != nil {
+		return err
+	}
+
 	chain, err := chain(e.Roster, e.ID)
 	if err != nil {
 		return err
@@ -113,30 +269,83 @@ func (e *Election) Store(data interface{}) error {
 	return nil
 }
 
-// Ballots accumulates all the ballots while only keeping the last ballot for each user.
-func (e *Election) Box() (*Box, error) {
+// Ballots returns all ballots appended to the election skipchain in casting order.
+func (e *Election) Ballots() ([]*Ballot, error) {
 	chain, err := chain(e.Roster, e.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Use map to only included a user's last ballot.
-	mapping := make(map[uint32]*Ballot)
+	ballots := make([]*Ballot, 0)
 	for _, block := range chain {
 		_, blob, _ := network.Unmarshal(block.Data, crypto.Suite)
 		if ballot, ok := blob.(*Ballot); ok {
-			mapping[ballot.User] = ballot
+			ballots = append(ballots, ballot)
 		}
 	}
+	return ballots, nil
+}
+
+// Box accumulates all the ballots while only keeping a single ballot for
+// each user as dictated by the re-voting policy of the election.
+func (e *Election) Box() (*Box, error) {
+	ballots, err := e.Ballots()
+	if err != nil {
+		return nil, err
+	}
+	return &Box{Ballots: e.filter(ballots)}, nil
+}
 
-	ballots := make([]*Ballot, 0)
-	for _, ballot := range mapping {
-		ballots = append(ballots, ballot)
+// Discarded returns the number of ballots superseded by the re-voting policy.
+func (e *Election) Discarded() (int, error) {
+	ballots, err := e.Ballots()
+	if err != nil {
+		return 0, err
 	}
-	return &Box{Ballots: ballots}, nil
+	return len(ballots) - len(e.filter(ballots)), nil
 }
 
-// Mixes returns all mixes created by the roster conodes.
+// HasCast checks if a given user has already cast a ballot in a contest.
+func (e *Election) HasCast(user, contest uint32) (bool, error) {
+	ballots, err := e.Ballots()
+	if err != nil {
+		return false, err
+	}
+
+	for _, ballot := range ballots {
+		if ballot.User == user && ballot.Contest == contest {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Turnout returns the distinct eligible voters that have cast a ballot in
+// order of their first ballot.
+func (e *Election) Turnout() ([]uint32, error) {
+	ballots, err := e.Ballots()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint32]bool)
+	voters := make([]uint32, 0)
+	for _, ballot := range ballots {
+		if !seen[ballot.User] && e.IsUser(ballot.User) {
+			seen[ballot.User] = true
+			voters = append(voters, ballot.User)
+		}
+	}
+	return voters, nil
+}
+
+// Quorate checks if a turnout meets the quorum of the election.
+func (e *Election) Quorate(turnout int) bool {
+	return uint64(turnout)*100 >= uint64(e.Quorum)*uint64(len(e.Users))
+}
+
+// Mixes returns all mixes created by the roster conodes. Referenced mixes are
+// fetched from the blob store.
 func (e *Election) Mixes() ([]*Mix, error) {
 	chain, err := chain(e.Roster, e.ID)
 	if err != nil {
@@ -145,7 +354,10 @@ func (e *Election) Mixes() ([]*Mix, error) {
 
 	mixes := make([]*Mix, 0)
 	for _, block := range chain {
-		_, blob, _ := network.Unmarshal(block.Data, crypto.Suite)
+		blob, err := e.payload(block.Data)
+		if err != nil {
+			return nil, err
+		}
 		if mix, ok := blob.(*Mix); ok {
 			mixes = append(mixes, mix)
 		}
@@ -154,7 +366,8 @@ func (e *Election) Mixes() ([]*Mix, error) {
 	return mixes, nil
 }
 
-// Partials returns the partial decryption for each roster conode.
+// Partials returns the partial decryption for each roster conode. Referenced
+// partials are fetched from the blob store.
 func (e *Election) Partials() ([]*Partial, error) {
 	chain, err := chain(e.Roster, e.ID)
 	if err != nil {
@@ -163,7 +376,10 @@ func (e *Election) Partials() ([]*Partial, error) {
 
 	partials := make([]*Partial, 0)
 	for _, block := range chain {
-		_, blob, _ := network.Unmarshal(block.Data, crypto.Suite)
+		blob, err := e.payload(block.Data)
+		if err != nil {
+			return nil, err
+		}
 		if partial, ok := blob.(*Partial); ok {
 			partials = append(partials, partial)
 		}
@@ -172,6 +388,33 @@ func (e *Election) Partials() ([]*Partial, error) {
 	return partials, nil
 }
 
+// Progress counts the mixes and partials on the election skipchain without
+// fetching them from the blob store.
+func (e *Election) Progress() (int, int, error) {
+	chain, err := chain(e.Roster, e.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	mixes, partials := 0, 0
+	for _, block := range chain {
+		_, blob, _ := network.Unmarshal(block.Data, crypto.Suite)
+		switch data := blob.(type) {
+		case *Mix:
+			mixes++
+		case *Partial:
+			partials++
+		case *Reference:
+			if data.Kind == MIX_BLOB {
+				mixes++
+			} else if data.Kind == PARTIAL_BLOB {
+				partials++
+			}
+		}
+	}
+	return mixes, partials, nil
+}
+
 // IsUser checks if a given user is a registered voter for the election.
 func (e *Election) IsUser(user uint32) bool {
 	for _, u := range e.Users {
@@ -187,6 +430,86 @@ func (e *Election) IsCreator(user uint32) bool {
 	return user == e.Creator
 }
 
+// Prover returns the shuffle proof system of the election.
+func (e *Election) Prover() (crypto.ShuffleProver, error) {
+	return crypto.Prover(e.Mixnet)
+}
+
+// RequiredMixes returns the minimum number of mixes for the election to be
+// shuffled. It defaults to the threshold of the election.
+func (e *Election) RequiredMixes() int {
+	if e.MinMixes == 0 {
+		return e.RequiredPartials()
+	}
+	return int(e.MinMixes)
+}
+
+// RequiredPartials returns the number of valid partials needed to decrypt
+// the election. It defaults to the DKG threshold of the roster.
+func (e *Election) RequiredPartials() int {
+	if e.Threshold == 0 {
+		return dkg.Threshold(len(e.Roster.List))
+	}
+	return int(e.Threshold)
+}
+
+// Poly returns the public polynomial of the DKG transcript.
+func (e *Election) Poly() *share.PubPoly {
+	return share.NewPubPoly(crypto.Suite, nil, e.Commits)
+}
+
+// VerifyTranscript checks that the DKG commitments commit to the election key
+// with the threshold of the election and that the public share of every
+// roster node is their evaluation at its share index.
+func (e *Election) VerifyTranscript() error {
+	if e.Key == nil || len(e.Commits) != e.RequiredPartials() ||
+		len(e.Shares) != len(e.Roster.List) {
+		return ERR_INVALID_TRANSCRIPT
+	}
+
+	poly := e.Poly()
+	if !poly.Commit().Equal(e.Key) {
+		return ERR_INVALID_TRANSCRIPT
+	}
+	for i, public := range e.Shares {
+		if public == nil || !poly.Eval(i).V.Equal(public) {
+			return ERR_INVALID_TRANSCRIPT
+		}
+	}
+	return nil
+}
+
+// Weight returns the weight of a user in the voter roll. Every ballot counts
+// once if the election has no weights or the user is not in the roll.
+func (e *Election) Weight(user uint32) uint32 {
+	for i, u := range e.Users {
+		if u == user && i < len(e.Weights) {
+			return e.Weights[i]
+		}
+	}
+	return 1
+}
+
+// filter applies the re-voting policy to the ballots of each contest. A user's
+// ballot retains the position of the user's first ballot in the list. Only the
+// last ballot is kept under LAST_WINS, otherwise the first one. Superseded
+// ballots are still stored on the skipchain to keep an audit trail.
+func (e *Election) filter(ballots []*Ballot) []*Ballot {
+	type key struct{ user, contest uint32 }
+
+	index := make(map[key]int)
+	filtered := make([]*Ballot, 0)
+	for _, ballot := range ballots {
+		if i, found := index[key{ballot.User, ballot.Contest}]; !found {
+			index[key{ballot.User, ballot.Contest}] = len(filtered)
+			filtered = append(filtered, ballot)
+		} else if e.Policy == LAST_WINS {
+			filtered[i] = ballot
+		}
+	}
+	return filtered
+}
+
 // storeBallots appends a list of ballots to the election skipchain.
 func (e *Election) storeBallots(ballots []*Ballot) error {
 	for _, ballot := range ballots {
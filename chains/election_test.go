@@ -9,13 +9,14 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
 )
 
 func TestFetchElection(t *testing.T) {
 	local := onet.NewLocalTest(crypto.Suite)
 	defer local.CloseAll()
 
-	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
 
 	_, err := FetchElection(roster, []byte{})
 	assert.NotNil(t, err)
@@ -28,25 +29,217 @@ func TestFetchElection(t *testing.T) {
 	assert.Equal(t, RUNNING, int(e.Stage))
 
 	election = &Election{Roster: roster, Stage: SHUFFLED}
-	_ = election.GenChain(10)
+	_ = election.GenChain(10, testutil.Keys(local, nodes)...)
 
 	e, _ = FetchElection(roster, election.ID)
 	assert.Equal(t, election.ID, e.ID)
 	assert.Equal(t, SHUFFLED, int(e.Stage))
 
 	election = &Election{Roster: roster, Stage: DECRYPTED}
-	_ = election.GenChain(10)
+	_ = election.GenChain(10, testutil.Keys(local, nodes)...)
 
 	e, _ = FetchElection(roster, election.ID)
 	assert.Equal(t, election.ID, e.ID)
 	assert.Equal(t, DECRYPTED, int(e.Stage))
 
-	election = &Election{Roster: roster, Stage: SHUFFLED}
+	election = &Election{Roster: roster, Stage: FINISHED}
+	_ = election.GenChain(10, testutil.Keys(local, nodes)...)
+
+	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, election.ID, e.ID)
+	assert.Equal(t, FINISHED, int(e.Stage))
+
+	election = &Election{Roster: roster, Stage: VOID}
 	_ = election.GenChain(10)
-	_ = election.Store(&Mix{Proof: []byte{}})
 
 	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, VOID, int(e.Stage))
+
+	election = &Election{Roster: roster, Stage: SHUFFLED}
+	_ = election.GenChain(10, testutil.Keys(local, nodes)...)
+	_ = election.Store(&Reference{Kind: MIX_BLOB})
+
+	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, CORRUPT, int(e.Stage))
+}
+
+func TestFetchElection_MinMixes(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+	keys := testutil.Keys(local, nodes)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(3)
+
+	box, _ := election.Box()
+	mixes := box.genMix(crypto.Neff{}, election.Key, 3)
+	for i, mix := range mixes {
+		_ = mix.Sign(election.ID, keys[i])
+	}
+	_ = election.storeMixes(mixes[:2])
+
+	e, _ := FetchElection(roster, election.ID)
 	assert.Equal(t, CORRUPT, int(e.Stage))
+
+	_ = election.Store(mixes[2])
+	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, SHUFFLED, int(e.Stage))
+}
+
+func TestFetchElection_Signers(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	keys := testutil.Keys(local, nodes)
+
+	election := &Election{Roster: roster, Stage: SHUFFLED}
+	_ = election.GenChain(3, keys...)
+
+	mixes, _ := election.Mixes()
+	mix := &Mix{Ballots: mixes[2].Ballots, Proofs: mixes[2].Proofs}
+	_ = mix.Sign(election.ID, keys[0])
+	_ = election.Store(mix)
+
+	e, _ := FetchElection(roster, election.ID)
+	assert.Equal(t, CORRUPT, int(e.Stage))
+
+	election = &Election{Roster: roster, Stage: DECRYPTED}
+	_ = election.GenChain(3, keys...)
+
+	x, _ := crypto.RandomKeyPair()
+	partial := &Partial{Flag: true}
+	_ = partial.Sign(election.ID, x)
+	assert.NotNil(t, election.Store(partial))
+	_ = election.Store(&Reference{Kind: PARTIAL_BLOB, Signer: partial.Signer,
+		Digest: partial.Digest(election.ID), Signature: partial.Signature})
+
+	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, CORRUPT, int(e.Stage))
+
+	election = &Election{Roster: roster, Stage: SHUFFLED}
+	_ = election.GenChain(3)
+
+	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, RUNNING, int(e.Stage))
+}
+
+func TestFetchElection_RosterChange(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+	keys := testutil.Keys(local, nodes)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(3)
+
+	sub := onet.NewRoster(roster.List[:3])
+	_ = election.Store(reshared(election, sub, keys[:2]))
+
+	e, _ := FetchElection(roster, election.ID)
+	assert.Equal(t, CORRUPT, int(e.Stage))
+
+	election = &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(3)
+	_ = election.Store(reshared(election, sub, keys[1:]))
+
+	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, RUNNING, int(e.Stage))
+	assert.Equal(t, 3, len(e.Roster.List))
+	assert.Equal(t, 2, e.RequiredPartials())
+
+	box, _ := e.Box()
+	mixes := box.genMix(crypto.Neff{}, e.Key, 3)
+	_ = mixes[0].Sign(e.ID, keys[0])
+	_ = mixes[1].Sign(e.ID, keys[1])
+	_ = mixes[2].Sign(e.ID, keys[3])
+	_ = e.storeMixes(mixes[:2])
+
+	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, SHUFFLED, int(e.Stage))
+
+	assert.NotNil(t, e.Store(mixes[2]))
+	_ = e.Store(&Reference{Kind: MIX_BLOB, Signer: mixes[2].Signer,
+		Digest: mixes[2].Digest(e.ID), Signature: mixes[2].Signature})
+	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, CORRUPT, int(e.Stage))
+
+	election = &Election{Roster: roster, Stage: SHUFFLED}
+	_ = election.GenChain(3, keys...)
+	_ = election.Store(reshared(election, sub, keys))
+
+	e, _ = FetchElection(roster, election.ID)
+	assert.Equal(t, CORRUPT, int(e.Stage))
+}
+
+func TestFetchElection_Mixnet(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &Election{Roster: roster, Stage: SHUFFLED, Mixnet: crypto.WIKSTROM}
+	_ = election.GenChain(5, testutil.Keys(local, nodes)...)
+
+	e, _ := FetchElection(roster, election.ID)
+	assert.Equal(t, SHUFFLED, int(e.Stage))
+	assert.Equal(t, crypto.WIKSTROM, e.Mixnet)
+
+	box, _ := e.Box()
+	mixes, _ := e.Mixes()
+	prover, _ := e.Prover()
+	assert.Nil(t, VerifyShuffle(prover, e.Key, box.Ballots, mixes[0]))
+}
+
+func TestRequiredMixes(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(4, 4, 1, true)
+
+	election := &Election{Roster: roster}
+	assert.Equal(t, 3, election.RequiredMixes())
+
+	election.MinMixes = 4
+	assert.Equal(t, 4, election.RequiredMixes())
+
+	election.MinMixes, election.Threshold = 0, 4
+	assert.Equal(t, 4, election.RequiredMixes())
+}
+
+func TestRequiredPartials(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+
+	election := &Election{Roster: roster}
+	assert.Equal(t, 3, election.RequiredPartials())
+
+	election = &Election{Roster: roster, Stage: DECRYPTED, Threshold: 4}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+	assert.Equal(t, 4, election.RequiredPartials())
+
+	e, _ := FetchElection(roster, election.ID)
+	assert.Equal(t, DECRYPTED, int(e.Stage))
+	assert.Equal(t, uint32(4), e.Threshold)
+}
+
+func TestProgress(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &Election{Roster: roster, Stage: DECRYPTED}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	mixes, partials, _ := election.Progress()
+	assert.Equal(t, 3, mixes)
+	assert.Equal(t, 3, partials)
 }
 
 func TestStore(t *testing.T) {
@@ -78,14 +271,97 @@ func TestBox(t *testing.T) {
 	assert.Equal(t, 10, len(box.Ballots))
 }
 
-func TestMixes(t *testing.T) {
+func TestBox_Policy(t *testing.T) {
 	local := onet.NewLocalTest(crypto.Suite)
 	defer local.CloseAll()
 
 	_, roster, _ := local.GenBigTree(3, 3, 1, true)
 
+	for _, policy := range []uint32{LAST_WINS, FIRST_WINS, SINGLE_BALLOT} {
+		election := &Election{Roster: roster, Stage: RUNNING, Policy: policy}
+		_ = election.GenChain(3)
+
+		_, X := crypto.RandomKeyPair()
+		election.Store(&Ballot{User: 0, Alpha: X, Beta: X})
+
+		box, _ := election.Box()
+		assert.Equal(t, 3, len(box.Ballots))
+		assert.Equal(t, uint32(0), box.Ballots[0].User)
+		assert.Equal(t, policy == LAST_WINS, box.Ballots[0].Alpha.Equal(X))
+
+		discarded, _ := election.Discarded()
+		assert.Equal(t, 1, discarded)
+	}
+}
+
+func TestBox_Contests(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &Election{Roster: roster, Stage: RUNNING, Policy: FIRST_WINS}
+	_ = election.GenChain(3)
+
+	_, X := crypto.RandomKeyPair()
+	election.Store(&Ballot{User: 0, Alpha: X, Beta: X, Contest: 1})
+
+	box, _ := election.Box()
+	assert.Equal(t, 4, len(box.Ballots))
+
+	cast, _ := election.HasCast(0, 1)
+	assert.True(t, cast)
+	cast, _ = election.HasCast(1, 1)
+	assert.False(t, cast)
+}
+
+func TestHasCast(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(3)
+
+	cast, _ := election.HasCast(2, 0)
+	assert.True(t, cast)
+	cast, _ = election.HasCast(3, 0)
+	assert.False(t, cast)
+}
+
+func TestTurnout(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &Election{Roster: roster, Users: []uint32{1, 2, 5}, Stage: RUNNING}
+	_ = election.GenChain(3)
+	_ = election.Store(&Ballot{User: 2})
+	_ = election.Store(&Ballot{User: 7})
+
+	voters, _ := election.Turnout()
+	assert.Equal(t, []uint32{1, 2}, voters)
+}
+
+func TestQuorate(t *testing.T) {
+	e := &Election{Users: []uint32{0, 1, 2, 3}, Quorum: 50}
+	assert.False(t, e.Quorate(1))
+	assert.True(t, e.Quorate(2))
+
+	e.Quorum = 0
+	assert.True(t, e.Quorate(0))
+}
+
+func TestMixes(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
 	election := &Election{Roster: roster, Stage: SHUFFLED}
-	_ = election.GenChain(10)
+	_ = election.GenChain(10, testutil.Keys(local, nodes)...)
 
 	mixes, _ := election.Mixes()
 	assert.Equal(t, 3, len(mixes))
@@ -95,15 +371,37 @@ func TestPartials(t *testing.T) {
 	local := onet.NewLocalTest(crypto.Suite)
 	defer local.CloseAll()
 
-	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
 
 	election := &Election{Roster: roster, Stage: DECRYPTED}
-	_ = election.GenChain(10)
+	_ = election.GenChain(10, testutil.Keys(local, nodes)...)
 
 	partials, _ := election.Partials()
 	assert.Equal(t, 3, len(partials))
 }
 
+func TestResult(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &Election{Roster: roster, Stage: DECRYPTED}
+	_ = election.GenChain(10, testutil.Keys(local, nodes)...)
+
+	result, _ := election.Result()
+	assert.Nil(t, result)
+
+	election.Store(&Result{Winners: []uint32{1}})
+	election.Store(&Result{Contest: 1, Winners: []uint32{0}})
+	result, _ = election.Result()
+	assert.Equal(t, []uint32{1}, result.Winners)
+
+	results, _ := election.Results()
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, uint32(1), results[1].Contest)
+}
+
 func TestIsUser(t *testing.T) {
 	e := &Election{Creator: 0, Users: []uint32{0}}
 	assert.True(t, e.IsUser(0))
@@ -115,3 +413,41 @@ func TestIsCreator(t *testing.T) {
 	assert.True(t, e.IsCreator(0))
 	assert.False(t, e.IsCreator(1))
 }
+
+func TestWeight(t *testing.T) {
+	e := &Election{Users: []uint32{0, 1}}
+	assert.Equal(t, uint32(1), e.Weight(0))
+
+	e.Weights = []uint32{5, 2}
+	assert.Equal(t, uint32(5), e.Weight(0))
+	assert.Equal(t, uint32(2), e.Weight(1))
+	assert.Equal(t, uint32(1), e.Weight(2))
+}
+
+func TestVerifyTranscript(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(4, 4, 1, true)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	assert.Equal(t, ERR_INVALID_TRANSCRIPT, election.VerifyTranscript())
+
+	_ = election.GenChain(3)
+	assert.Nil(t, election.VerifyTranscript())
+
+	e, _ := FetchElection(roster, election.ID)
+	assert.Nil(t, e.VerifyTranscript())
+
+	e.Shares[1], e.Shares[2] = e.Shares[2], e.Shares[1]
+	assert.Equal(t, ERR_INVALID_TRANSCRIPT, e.VerifyTranscript())
+
+	_, X := crypto.RandomKeyPair()
+	e, _ = FetchElection(roster, election.ID)
+	e.Key = X
+	assert.Equal(t, ERR_INVALID_TRANSCRIPT, e.VerifyTranscript())
+
+	e, _ = FetchElection(roster, election.ID)
+	e.Threshold = 4
+	assert.Equal(t, ERR_INVALID_TRANSCRIPT, e.VerifyTranscript())
+}
@@ -0,0 +1,124 @@
+package chains
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dedis/kyber"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/network"
+
+	"github.com/qantik/nevv/blobs"
+	"github.com/qantik/nevv/crypto"
+)
+
+const (
+	// Kinds of off-chain data.
+	MIX_BLOB = iota
+	PARTIAL_BLOB
+)
+
+var ERR_INVALID_REFERENCE = errors.New("Reference does not point to the expected data")
+
+// Reference replaces a mix or a partial decryption on the election skipchain.
+// The data itself is kept in the blob store of the roster and is addressed by
+// the SHA-256 hash of its encoding.
+// The signature of the creator is copied from the data so that it can be
+// checked without fetching the blob.
+type Reference struct {
+	Kind uint32 // Kind of the referenced data.
+	Hash []byte // Hash is the content address of the data.
+	Size uint32 // Size of the encoded data in bytes.
+
+	Signer    kyber.Point // Signer is the server identity key of the creator.
+	Digest    []byte      // Digest of the referenced data.
+	Signature []byte      // Signature of the digest by the creator.
+}
+
+func init() {
+	network.RegisterMessage(Reference{})
+	blobs.Authenticate(vouch)
+}
+
+// vouch lets the blob store accept only mixes and partials of an election
+// signed by a conode of its roster.
+func vouch(node *onet.Roster, id, data []byte) error {
+	election, err := FetchElection(node, id)
+	if err != nil {
+		return err
+	}
+
+	_, blob, err := network.Unmarshal(data, crypto.Suite)
+	if err != nil {
+		return err
+	}
+	switch blob.(type) {
+	case *Mix, *Partial:
+		_, err = election.authenticate(blob)
+		return err
+	}
+	return ERR_INVALID_REFERENCE
+}
+
+// offload puts a mix or a partial into the blob store of the roster and
+// returns a signed reference to it.
+func (e *Election) offload(data interface{}) (*Reference, error) {
+	buf, err := network.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := blobs.Store(e.Roster, e.ID, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := &Reference{Hash: hash, Size: uint32(len(buf))}
+	switch data := data.(type) {
+	case *Mix:
+		ref.Kind, ref.Signer, ref.Digest, ref.Signature =
+			MIX_BLOB, data.Signer, data.Digest(e.ID), data.Signature
+	case *Partial:
+		ref.Kind, ref.Signer, ref.Digest, ref.Signature =
+			PARTIAL_BLOB, data.Signer, data.Digest(e.ID), data.Signature
+	default:
+		return nil, ERR_INVALID_REFERENCE
+	}
+	return ref, nil
+}
+
+// resolve fetches the data of a reference from the blob store. The content is
+// checked against the hash, the kind and the digest of the reference. Partials
+// must moreover be complete.
+func (e *Election) resolve(ref *Reference) (interface{}, error) {
+	buf, err := blobs.Retrieve(e.Roster, ref.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	_, blob, err := network.Unmarshal(buf, crypto.Suite)
+	if err != nil {
+		return nil, err
+	}
+
+	if mix, ok := blob.(*Mix); ok && ref.Kind == MIX_BLOB &&
+		bytes.Equal(mix.Digest(e.ID), ref.Digest) {
+		return blob, nil
+	} else if partial, ok := blob.(*Partial); ok && ref.Kind == PARTIAL_BLOB &&
+		bytes.Equal(partial.Digest(e.ID), ref.Digest) {
+		if err := partial.Complete(); err != nil {
+			return nil, err
+		}
+		return blob, nil
+	}
+	return nil, ERR_INVALID_REFERENCE
+}
+
+// payload returns the data of a block, resolving references to the blob store.
+func (e *Election) payload(data []byte) (interface{}, error) {
+	_, blob, _ := network.Unmarshal(data, crypto.Suite)
+	if ref, ok := blob.(*Reference); ok {
+		return e.resolve(ref)
+	}
+	return blob, nil
+}
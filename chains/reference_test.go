@@ -0,0 +1,106 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/proof/dleq"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/network"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestOffload(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	keys := testutil.Keys(local, nodes)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(2)
+
+	mix := &Mix{Node: "node", Proofs: [][]byte{}}
+	_ = mix.Sign(election.ID, keys[0])
+	_ = election.Store(mix)
+
+	chain, _ := client.GetUpdateChain(roster, election.ID)
+	_, blob, _ := network.Unmarshal(chain.Update[len(chain.Update)-1].Data, crypto.Suite)
+	ref := blob.(*Reference)
+	assert.Equal(t, uint32(MIX_BLOB), ref.Kind)
+	assert.True(t, ref.Size > 0)
+
+	mixes, _ := election.Mixes()
+	assert.Equal(t, "node", mixes[0].Node)
+}
+
+func TestOffload_Unauthenticated(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(2)
+
+	assert.NotNil(t, election.Store(&Mix{Node: "node", Proofs: [][]byte{}}))
+
+	x, _ := crypto.RandomKeyPair()
+	mix := &Mix{Node: "node", Proofs: [][]byte{}}
+	_ = mix.Sign(election.ID, x)
+	assert.NotNil(t, election.Store(mix))
+
+	_, err := (&Election{Roster: roster}).offload(mix)
+	assert.NotNil(t, err)
+
+	mixes, _ := election.Mixes()
+	assert.Equal(t, 0, len(mixes))
+}
+
+func TestOffload_Incomplete(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	keys := testutil.Keys(local, nodes)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(2)
+
+	partial := &Partial{Points: []kyber.Point{crypto.Suite.Point()}, Proofs: []*dleq.Proof{nil}}
+	_ = partial.Sign(election.ID, keys[0])
+	assert.NotNil(t, election.Store(partial))
+
+	partials, _ := election.Partials()
+	assert.Equal(t, 0, len(partials))
+}
+
+func TestResolve(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	keys := testutil.Keys(local, nodes)
+
+	election := &Election{Roster: roster, Stage: RUNNING}
+	_ = election.GenChain(2)
+
+	partial := &Partial{Index: 1}
+	_ = partial.Sign(election.ID, keys[1])
+	ref, _ := election.offload(partial)
+
+	blob, _ := election.resolve(ref)
+	assert.Equal(t, 1, blob.(*Partial).Index)
+
+	ref.Kind = MIX_BLOB
+	_, err := election.resolve(ref)
+	assert.Equal(t, ERR_INVALID_REFERENCE, err)
+
+	ref.Hash = []byte{}
+	_, err = election.resolve(ref)
+	assert.NotNil(t, err)
+}
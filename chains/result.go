@@ -0,0 +1,71 @@
+package chains
+
+import (
+	"github.com/dedis/onet/network"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+const (
+	// Counting methods.
+	PLURALITY = iota
+	APPROVAL
+	IRV
+	STV
+)
+
+// Schema describes how the plaintexts of an election are decoded and counted.
+// Every byte of a plaintext is the index of a candidate. Ranked methods
+// expect the bytes to be ordered by preference.
+type Schema struct {
+	Candidates []string // Candidates is the list of choices.
+	Method     uint32   // Method is the counting method of the tally.
+	Seats      uint32   // Seats is the number of winners.
+}
+
+// Round is the breakdown of a single counting round.
+type Round struct {
+	Counts     []float64 // Counts is the vote total of each candidate.
+	Elected    []uint32  // Elected are the candidates elected in this round.
+	Eliminated []uint32  // Eliminated are the candidates eliminated in this round.
+}
+
+// Result is the outcome of the tally of a contest. One result per contest is
+// appended to the election skipchain after the reconstruction of the plaintexts.
+type Result struct {
+	Contest uint32   // Contest is the index of the counted contest.
+	Winners []uint32 // Winners are the elected candidates in order of election.
+	Rounds  []*Round // Rounds is the breakdown of each counting round.
+	Invalid uint32   // Invalid is the number of spoiled ballots.
+}
+
+func init() {
+	network.RegisterMessages(Schema{}, Round{}, Result{})
+}
+
+// Result returns the tally result of the first contest stored on the election
+// skipchain or nil if the election has not been tallied yet.
+func (e *Election) Result() (*Result, error) {
+	results, err := e.Results()
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// Results returns the tally results of all contests in storage order.
+func (e *Election) Results() ([]*Result, error) {
+	chain, err := chain(e.Roster, e.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Result, 0)
+	for _, block := range chain {
+		_, blob, _ := network.Unmarshal(block.Data, crypto.Suite)
+		if result, ok := blob.(*Result); ok {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
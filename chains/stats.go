@@ -0,0 +1,71 @@
+package chains
+
+import (
+	"github.com/dedis/onet/network"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+// Timing records when a protocol phase or a node contribution started and
+// how long it took. Phase timings are appended to the election skipchain at
+// the end of each phase.
+type Timing struct {
+	Name     string // Name of the phase or the contributing node.
+	Start    int64  // Start in unix nanoseconds.
+	Duration int64  // Duration in nanoseconds.
+}
+
+// Stats summarises the size and the timings of an election skipchain.
+type Stats struct {
+	Ballots  uint32    // Ballots is the number of cast ballots.
+	Revotes  uint32    // Revotes is the number of ballots cast over an earlier one.
+	Blocks   uint32    // Blocks is the length of the skipchain.
+	Size     uint64    // Size of the block and referenced blob data in bytes.
+	Phases   []*Timing // Phases are the timings of the protocol phases.
+	Mixes    []*Timing // Mixes are the timings of each shuffle.
+	Partials []*Timing // Partials are the timings of each partial decryption.
+}
+
+func init() {
+	network.RegisterMessages(Timing{}, Stats{})
+}
+
+// Stats collects the statistics of the election skipchain.
+func (e *Election) Stats() (*Stats, error) {
+	chain, err := chain(e.Roster, e.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ user, contest uint32 }
+
+	stats := &Stats{Phases: []*Timing{}, Mixes: []*Timing{}, Partials: []*Timing{}}
+	seen := make(map[key]bool)
+	for _, block := range chain {
+		stats.Blocks++
+		stats.Size += uint64(len(block.Data))
+
+		_, blob, _ := network.Unmarshal(block.Data, crypto.Suite)
+		if ref, ok := blob.(*Reference); ok {
+			stats.Size += uint64(ref.Size)
+			if blob, err = e.resolve(ref); err != nil {
+				return nil, err
+			}
+		}
+		switch data := blob.(type) {
+		case *Ballot:
+			stats.Ballots++
+			if seen[key{data.User, data.Contest}] {
+				stats.Revotes++
+			}
+			seen[key{data.User, data.Contest}] = true
+		case *Timing:
+			stats.Phases = append(stats.Phases, data)
+		case *Mix:
+			stats.Mixes = append(stats.Mixes, &Timing{data.Node, data.Start, data.Duration})
+		case *Partial:
+			stats.Partials = append(stats.Partials, &Timing{data.Node, data.Start, data.Duration})
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,33 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/dedis/onet"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestStats(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &Election{Roster: roster, Stage: DECRYPTED}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+	_ = election.Store(&Ballot{User: 1})
+	_ = election.Store(&Timing{Name: "decrypt", Start: 1, Duration: 2})
+
+	stats, _ := election.Stats()
+	assert.Equal(t, uint32(4), stats.Ballots)
+	assert.Equal(t, uint32(1), stats.Revotes)
+	assert.Equal(t, uint32(1+1+4+3+3+1), stats.Blocks)
+	assert.True(t, stats.Size > 0)
+	assert.Equal(t, []*Timing{&Timing{"decrypt", 1, 2}}, stats.Phases)
+	assert.Equal(t, 3, len(stats.Mixes))
+	assert.Equal(t, 3, len(stats.Partials))
+}
@@ -0,0 +1,130 @@
+package chains
+
+import (
+	"github.com/dedis/kyber"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+// Rejection is the reason why a ballot is refused by a validator.
+type Rejection int
+
+const (
+	// Ballot rejection reasons.
+	INVALID_POINT Rejection = iota
+	DUPLICATE_CIPHERTEXT
+	BOX_FULL
+	INVALID_PROOF
+)
+
+var rejections = map[Rejection]string{
+	INVALID_POINT:        "Ballot contains an invalid point",
+	DUPLICATE_CIPHERTEXT: "Ballot ciphertext has already been cast",
+	BOX_FULL:             "Ballot box is full",
+	INVALID_PROOF:        "Ballot proof is missing or invalid",
+}
+
+// Error returns the description of the rejection reason.
+func (r Rejection) Error() string {
+	return rejections[r]
+}
+
+// Validator inspects a ballot before it is cast given the election and the
+// list of ballots already appended to the election skipchain.
+type Validator func(election *Election, ballots []*Ballot, ballot *Ballot) error
+
+// Validate runs a ballot through a chain of validators and returns the
+// rejection of the first validator that fails.
+func (e *Election) Validate(ballot *Ballot, validators ...Validator) error {
+	ballots, err := e.Ballots()
+	if err != nil {
+		return err
+	}
+
+	for _, validator := range validators {
+		if err := validator(e, ballots, ballot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidPoints rejects ballots with missing points, points that are not on the
+// curve, the identity element or any other point of small order.
+func ValidPoints(election *Election, ballots []*Ballot, ballot *Ballot) error {
+	if !valid(ballot.Alpha) || !valid(ballot.Beta) {
+		return INVALID_POINT
+	}
+	return nil
+}
+
+// Unique rejects ballots whose ciphertext is already on the skipchain.
+func Unique(election *Election, ballots []*Ballot, ballot *Ballot) error {
+	for _, b := range ballots {
+		if b.Alpha == nil || b.Beta == nil {
+			continue
+		}
+		if b.Alpha.Equal(ballot.Alpha) && b.Beta.Equal(ballot.Beta) {
+			return DUPLICATE_CIPHERTEXT
+		}
+	}
+	return nil
+}
+
+// Limit returns a validator that rejects ballots once n ballots are cast.
+func Limit(n int) Validator {
+	return func(election *Election, ballots []*Ballot, ballot *Ballot) error {
+		if len(ballots) >= n {
+			return BOX_FULL
+		}
+		return nil
+	}
+}
+
+// Proven rejects ballots without a valid proof of knowledge of the ephemeral
+// key if the election requires them.
+func Proven(election *Election, ballots []*Ballot, ballot *Ballot) error {
+	if !election.Proofs {
+		return nil
+	} else if ballot.Alpha == nil || ballot.Beta == nil {
+		return INVALID_PROOF
+	}
+
+	digest := ballot.Digest(election.ID)
+	if crypto.VerifyEncryption(ballot.Alpha, ballot.Beta, digest, ballot.Proof) != nil {
+		return INVALID_PROOF
+	}
+	return nil
+}
+
+// VerifyBox checks the points and, if required, the proofs of every ballot
+// in a box before it is shuffled.
+func VerifyBox(election *Election, ballots []*Ballot) error {
+	for _, ballot := range ballots {
+		if err := ValidPoints(election, nil, ballot); err != nil {
+			return err
+		} else if err := Proven(election, nil, ballot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valid checks if a point can be decoded and is neither of small order.
+func valid(point kyber.Point) bool {
+	if point == nil {
+		return false
+	}
+
+	buf, err := point.MarshalBinary()
+	if err != nil {
+		return false
+	}
+	decoded := crypto.Suite.Point()
+	if err = decoded.UnmarshalBinary(buf); err != nil {
+		return false
+	}
+
+	cofactor := crypto.Suite.Scalar().SetInt64(8)
+	return !crypto.Suite.Point().Mul(cofactor, decoded).Equal(crypto.Suite.Point().Null())
+}
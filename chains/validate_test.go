@@ -0,0 +1,65 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+func TestValidPoints(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	null := crypto.Suite.Point().Null()
+
+	assert.Nil(t, ValidPoints(nil, nil, &Ballot{Alpha: X, Beta: X}))
+	assert.Equal(t, INVALID_POINT, ValidPoints(nil, nil, &Ballot{Alpha: X}))
+	assert.Equal(t, INVALID_POINT, ValidPoints(nil, nil, &Ballot{Alpha: null, Beta: X}))
+}
+
+func TestUnique(t *testing.T) {
+	_, X1 := crypto.RandomKeyPair()
+	_, X2 := crypto.RandomKeyPair()
+	ballots := []*Ballot{&Ballot{Alpha: X1, Beta: X2}}
+
+	assert.Nil(t, Unique(nil, ballots, &Ballot{Alpha: X2, Beta: X1}))
+	assert.Equal(t, DUPLICATE_CIPHERTEXT, Unique(nil, ballots, &Ballot{Alpha: X1, Beta: X2}))
+}
+
+func TestLimit(t *testing.T) {
+	ballots := []*Ballot{&Ballot{}, &Ballot{}}
+
+	assert.Nil(t, Limit(3)(nil, ballots, &Ballot{}))
+	assert.Equal(t, BOX_FULL, Limit(2)(nil, ballots, &Ballot{}))
+}
+
+func TestProven(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	election := &Election{ID: []byte{0}, Proofs: true}
+
+	ballot := &Ballot{User: 0}
+	ballot.Alpha, ballot.Beta, ballot.Proof, _ = crypto.ProveEncryption(X, []byte{0},
+		ballot.Digest(election.ID))
+	assert.Nil(t, Proven(election, nil, ballot))
+
+	ballot.User = 1
+	assert.Equal(t, INVALID_PROOF, Proven(election, nil, ballot))
+
+	election.Proofs = false
+	assert.Nil(t, Proven(election, nil, ballot))
+}
+
+func TestVerifyBox(t *testing.T) {
+	_, X := crypto.RandomKeyPair()
+	election := &Election{ID: []byte{0}}
+	ballots := genBox(X, 3).Ballots
+
+	assert.Nil(t, VerifyBox(election, ballots))
+
+	election.Proofs = true
+	assert.Equal(t, INVALID_PROOF, VerifyBox(election, ballots))
+
+	election.Proofs = false
+	ballots[1].Beta = crypto.Suite.Point().Null()
+	assert.Equal(t, INVALID_POINT, VerifyBox(election, ballots))
+}
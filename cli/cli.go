@@ -1,15 +1,21 @@
 package main
 
 import (
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/qantik/nevv/api"
+	"github.com/qantik/nevv/chains"
 	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/export"
 	"github.com/qantik/nevv/service"
+	"github.com/qantik/nevv/simulator"
 
 	"github.com/dedis/kyber"
 	"github.com/dedis/onet"
@@ -17,6 +23,18 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		exportElection(os.Args[2:])
+		return
+	} else if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		simulate(os.Args[2:])
+		return
+	}
+	link()
+}
+
+// link connects the nevv service to the master skipchain.
+func link() {
 	argRoster := flag.String("roster", "", "path to group toml file")
 	_ = flag.String("key", "", "client-side public key")
 	argAdmins := flag.String("admins", "", "list of admin scipers")
@@ -47,6 +65,79 @@ func main() {
 	fmt.Println("Master ID:", reply.ID)
 }
 
+// exportElection writes the bundle of an election to a directory.
+func exportElection(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	argRoster := flags.String("roster", "", "path to group toml file")
+	argID := flags.String("id", "", "hex encoded election skipchain ID")
+	argOut := flags.String("out", ".", "output directory of the bundle")
+	flags.Parse(args)
+
+	roster, err := parseRoster(*argRoster)
+	if err != nil {
+		panic(err)
+	}
+
+	id, err := hex.DecodeString(*argID)
+	if err != nil {
+		panic(err)
+	}
+
+	election, err := chains.FetchElection(roster, id)
+	if err != nil {
+		panic(err)
+	}
+
+	bundle, err := export.Build(election)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = os.MkdirAll(*argOut, 0755); err != nil {
+		panic(err)
+	}
+	for _, file := range bundle.Files {
+		if err = ioutil.WriteFile(filepath.Join(*argOut, file.Name), file.Data, 0644); err != nil {
+			panic(err)
+		}
+	}
+
+	fmt.Println("Exported", len(bundle.Files), "files to", *argOut)
+}
+
+// simulate runs offline elections for every given number of ballots and
+// prints a table of the phase timings.
+func simulate(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	argNodes := flags.Int("nodes", 3, "number of roster nodes")
+	argBallots := flags.String("ballots", "100", "list of ballot counts")
+	argMixes := flags.Int("mixes", 0, "number of shuffles, 0 for one per node")
+	argMixnet := flags.String("mixnet", "", "shuffle proof system")
+	flags.Parse(args)
+
+	counts, err := parseCounts(*argBallots)
+	if err != nil {
+		panic(err)
+	}
+
+	reports := make([]*simulator.Report, len(counts))
+	for i, count := range counts {
+		config := simulator.Config{
+			Nodes:   *argNodes,
+			Ballots: count,
+			Mixes:   *argMixes,
+			Mixnet:  *argMixnet,
+		}
+		if reports[i], err = simulator.Run(config); err != nil {
+			panic(err)
+		}
+	}
+
+	if err = simulator.Table(os.Stdout, reports); err != nil {
+		panic(err)
+	}
+}
+
 // parseRoster reads a Dedis group toml file a converts it to a cothority roster.
 func parseRoster(path string) (*onet.Roster, error) {
 	file, err := os.Open(path)
@@ -82,3 +173,16 @@ func parseAdmins(scipers string) ([]uint32, error) {
 	}
 	return admins, nil
 }
+
+// parseCounts converts a string of comma-separated numbers to a list of integers.
+func parseCounts(list string) ([]int, error) {
+	counts := make([]int, 0)
+	for _, field := range strings.Split(list, ",") {
+		count, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
@@ -1,20 +1,60 @@
 package crypto
 
 import (
+	"errors"
+
 	"github.com/dedis/kyber"
-	"github.com/dedis/kyber/proof"
-	"github.com/dedis/kyber/shuffle"
+	"github.com/dedis/kyber/proof/dleq"
+	"github.com/dedis/kyber/sign/schnorr"
 	"github.com/dedis/kyber/util/random"
 )
 
 func Encrypt(public kyber.Point, message []byte) (K, C kyber.Point) {
+	_, K, C = encrypt(public, message)
+	return
+}
+
+// ProveEncryption ElGamal-encrypts a message and creates a Schnorr proof of
+// knowledge of the ephemeral key that is bound to C and the given context.
+func ProveEncryption(public kyber.Point, message, context []byte) (
+	K, C kyber.Point, proof []byte, err error) {
+
+	var k kyber.Scalar
+	k, K, C = encrypt(public, message)
+	digest, err := bind(context, C)
+	if err != nil {
+		return
+	}
+	proof, err = schnorr.Sign(Suite, k, digest)
+	return
+}
+
+// VerifyEncryption checks the proof of knowledge of the ephemeral key K.
+func VerifyEncryption(K, C kyber.Point, context, proof []byte) error {
+	digest, err := bind(context, C)
+	if err != nil {
+		return err
+	}
+	return schnorr.Verify(Suite, K, digest, proof)
+}
+
+// bind appends the encoding of C to the context.
+func bind(context []byte, C kyber.Point) ([]byte, error) {
+	buf, err := C.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, context...), buf...), nil
+}
+
+func encrypt(public kyber.Point, message []byte) (k kyber.Scalar, K, C kyber.Point) {
 	M := Suite.Point().Embed(message, random.New())
 
 	// ElGamal-encrypt the point to produce ciphertext (K,C).
-	k := Suite.Scalar().Pick(random.New()) // ephemeral private key
-	K = Suite.Point().Mul(k, nil)          // ephemeral DH public key
-	S := Suite.Point().Mul(k, public)      // ephemeral DH shared secret
-	C = S.Add(S, M)                        // message blinded with secret
+	k = Suite.Scalar().Pick(random.New()) // ephemeral private key
+	K = Suite.Point().Mul(k, nil)         // ephemeral DH public key
+	S := Suite.Point().Mul(k, public)     // ephemeral DH shared secret
+	C = S.Add(S, M)                       // message blinded with secret
 	return
 }
 
@@ -24,6 +64,26 @@ func Decrypt(private kyber.Scalar, K, C kyber.Point) kyber.Point {
 	return Suite.Point().Sub(C, S)     // use to un-blind the message
 }
 
+// ProveDecryption performs a partial decryption of (K,C) with a secret share x
+// and creates a Chaum-Pedersen proof that log_B(xB) equals log_K(xK).
+func ProveDecryption(secret kyber.Scalar, K, C kyber.Point) (kyber.Point, *dleq.Proof, error) {
+	proof, _, S, err := dleq.NewDLEQProof(Suite, Base, K, secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Suite.Point().Sub(C, S), proof, nil
+}
+
+// VerifyDecryption checks that the partial decryption M of (K,C) was created
+// with the secret share belonging to the public share X.
+func VerifyDecryption(X, K, C, M kyber.Point, proof *dleq.Proof) error {
+	if proof == nil || M == nil {
+		return errors.New("missing decryption proof")
+	}
+	S := Suite.Point().Sub(C, M)
+	return proof.Verify(Suite, Base, K, X, S)
+}
+
 // // Decrypt performs the standard ElGamal decryption algorithm.
 // // m = beta / (alpha^secret).
 // func Decrypt(secret abstract.Scalar, alpha, beta abstract.Point) abstract.Point {
@@ -73,8 +133,3 @@ func Decrypt(private kyber.Scalar, K, C kyber.Point) kyber.Point {
 // 	}
 // 	return gamma, delta, pi, prover
 // }
-
-func Verify(tag []byte, public kyber.Point, x, y, v, w []kyber.Point) error {
-	verifier := shuffle.Verifier(Suite, nil, public, x, y, v, w)
-	return proof.HashVerify(Suite, "", verifier, tag)
-}
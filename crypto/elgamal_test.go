@@ -15,3 +15,26 @@ func TestElGamal(t *testing.T) {
 	dec, _ := Decrypt(secret, K, C).Data()
 	assert.Equal(t, message, dec)
 }
+
+func TestProveEncryption(t *testing.T) {
+	_, public := RandomKeyPair()
+	context := []byte("nevv")
+
+	K, C, proof, _ := ProveEncryption(public, []byte{0}, context)
+	assert.Nil(t, VerifyEncryption(K, C, context, proof))
+	assert.NotNil(t, VerifyEncryption(K, C, []byte("vven"), proof))
+	assert.NotNil(t, VerifyEncryption(K, public, context, proof))
+	assert.NotNil(t, VerifyEncryption(public, C, context, proof))
+}
+
+func TestProveDecryption(t *testing.T) {
+	secret, public := RandomKeyPair()
+	K, C := Encrypt(public, []byte("nevv"))
+
+	M, proof, _ := ProveDecryption(secret, K, C)
+	assert.True(t, M.Equal(Decrypt(secret, K, C)))
+	assert.Nil(t, VerifyDecryption(public, K, C, M, proof))
+	assert.NotNil(t, VerifyDecryption(public, K, C, K, proof))
+	assert.NotNil(t, VerifyDecryption(K, K, C, M, proof))
+	assert.NotNil(t, VerifyDecryption(public, K, C, M, nil))
+}
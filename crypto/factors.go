@@ -0,0 +1,94 @@
+package crypto
+
+import (
+	"sync"
+
+	"github.com/dedis/kyber"
+)
+
+// Factor is a re-encryption factor r together with its group elements, such
+// that a pair (x, y) is re-encrypted as (x + Alpha, y + Beta).
+type Factor struct {
+	R     kyber.Scalar // R is the random scalar.
+	Alpha kyber.Point  // Alpha is R times the base point.
+	Beta  kyber.Point  // Beta is R times the public key.
+}
+
+// NewFactor computes a fresh re-encryption factor under a public key.
+func NewFactor(public kyber.Point) *Factor {
+	r := Suite.Scalar().Pick(Stream)
+	return &Factor{
+		R:     r,
+		Alpha: Suite.Point().Mul(r, nil),
+		Beta:  Suite.Point().Mul(r, public),
+	}
+}
+
+// Factors computes n fresh re-encryption factors under a public key.
+func Factors(public kyber.Point, n int) []*Factor {
+	factors := make([]*Factor, n)
+	for i := range factors {
+		factors[i] = NewFactor(public)
+	}
+	return factors
+}
+
+// Pool is a stock of re-encryption factors precomputed under a public key.
+// Every factor is handed out only once.
+type Pool struct {
+	sync.Mutex
+
+	key     kyber.Point
+	factors []*Factor
+	drained bool // drained stops the filling once factors have been taken.
+}
+
+// NewPool creates an empty pool of factors under a public key.
+func NewPool(key kyber.Point) *Pool {
+	return &Pool{key: key, factors: make([]*Factor, 0)}
+}
+
+// Fill precomputes factors until the pool holds n of them. It returns early
+// as soon as factors are taken from the pool.
+func (p *Pool) Fill(n int) {
+	for {
+		p.Lock()
+		if p.drained || len(p.factors) >= n {
+			p.Unlock()
+			return
+		}
+		p.Unlock()
+
+		factor := NewFactor(p.key)
+		p.Lock()
+		p.factors = append(p.factors, factor)
+		p.Unlock()
+	}
+}
+
+// Take removes n factors from the pool and computes the missing ones if the
+// stock runs short. A nil pool returns nil, letting provers pick their own.
+func (p *Pool) Take(n int) []*Factor {
+	if p == nil {
+		return nil
+	}
+
+	p.Lock()
+	p.drained = true
+	k := n
+	if k > len(p.factors) {
+		k = len(p.factors)
+	}
+	taken := p.factors[len(p.factors)-k:]
+	p.factors = p.factors[:len(p.factors)-k]
+	p.Unlock()
+
+	return append(append([]*Factor{}, taken...), Factors(p.key, n-k)...)
+}
+
+// Size returns the number of factors in stock.
+func (p *Pool) Size() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.factors)
+}
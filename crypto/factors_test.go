@@ -0,0 +1,36 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactor(t *testing.T) {
+	_, X := RandomKeyPair()
+	factor := NewFactor(X)
+	assert.True(t, factor.Alpha.Equal(Suite.Point().Mul(factor.R, nil)))
+	assert.True(t, factor.Beta.Equal(Suite.Point().Mul(factor.R, X)))
+}
+
+func TestPool(t *testing.T) {
+	_, X := RandomKeyPair()
+	pool := NewPool(X)
+	pool.Fill(5)
+	assert.Equal(t, 5, pool.Size())
+
+	factors := pool.Take(3)
+	assert.Equal(t, 3, len(factors))
+	assert.Equal(t, 2, pool.Size())
+
+	factors = pool.Take(4)
+	assert.Equal(t, 4, len(factors))
+	assert.Equal(t, 0, pool.Size())
+	assert.True(t, factors[3].Beta.Equal(Suite.Point().Mul(factors[3].R, X)))
+
+	pool.Fill(5)
+	assert.Equal(t, 0, pool.Size())
+
+	var empty *Pool
+	assert.Nil(t, empty.Take(3))
+}
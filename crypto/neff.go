@@ -0,0 +1,50 @@
+package crypto
+
+import (
+	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/proof"
+	"github.com/dedis/kyber/shuffle"
+)
+
+// Neff is the verifiable shuffle of ElGamal pairs by Andrew Neff as
+// implemented by kyber, made non-interactive with the Fiat-Shamir heuristic.
+type Neff struct{}
+
+// Shuffle permutes and re-encrypts the pairs and proves the shuffle. It
+// mirrors kyber's shuffle.Shuffle with the blinding factors supplied.
+func (Neff) Shuffle(public kyber.Point, x, y []kyber.Point, factors []*Factor) (
+	[]kyber.Point, []kyber.Point, []byte, error) {
+
+	n := len(x)
+	if n == 0 || len(y) != n {
+		return nil, nil, nil, ERR_EMPTY_SHUFFLE
+	}
+	pi := permutation(n)
+
+	// Output i is the re-encryption of input pi[i] with factor pi[i].
+	factors = fresh(public, factors, n)
+	beta := make([]kyber.Scalar, n)
+	v, w := make([]kyber.Point, n), make([]kyber.Point, n)
+	for i := range v {
+		beta[i] = factors[i].R
+		v[i] = Suite.Point().Add(x[pi[i]], factors[pi[i]].Alpha)
+		w[i] = Suite.Point().Add(y[pi[i]], factors[pi[i]].Beta)
+	}
+
+	prover := func(ctx proof.ProverContext) error {
+		ps := shuffle.PairShuffle{}
+		ps.Init(Suite, n)
+		return ps.Prove(pi, nil, public, beta, x, y, Stream, ctx)
+	}
+	tag, err := proof.HashProve(Suite, "", prover)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return v, w, tag, nil
+}
+
+// Verify checks the Neff proof of a shuffle.
+func (Neff) Verify(tag []byte, public kyber.Point, x, y, v, w []kyber.Point) error {
+	verifier := shuffle.Verifier(Suite, nil, public, x, y, v, w)
+	return proof.HashVerify(Suite, "", verifier, tag)
+}
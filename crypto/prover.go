@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	"errors"
+
+	"github.com/dedis/kyber"
+)
+
+const (
+	// Shuffle proof systems.
+	NEFF     = "neff"
+	WIKSTROM = "terelius-wikstrom"
+)
+
+var (
+	ERR_UNKNOWN_PROVER = errors.New("Unknown shuffle proof system")
+	ERR_EMPTY_SHUFFLE  = errors.New("Nothing to shuffle")
+)
+
+// ShuffleProver permutes and re-encrypts lists of ElGamal ciphertext pairs
+// (x, y) under a public key and proves the correctness of the shuffle.
+type ShuffleProver interface {
+	// Shuffle returns the shuffled pairs (v, w) with a proof. The pairs are
+	// re-encrypted with the given factors, or fresh ones if there are not
+	// exactly as many factors as pairs.
+	Shuffle(public kyber.Point, x, y []kyber.Point, factors []*Factor) (v, w []kyber.Point,
+		proof []byte, err error)
+	// Verify checks that (v, w) is a shuffle of (x, y) given the proof.
+	Verify(proof []byte, public kyber.Point, x, y, v, w []kyber.Point) error
+}
+
+// provers maps the names of the proof systems to their implementations.
+var provers = map[string]ShuffleProver{
+	NEFF:     Neff{},
+	WIKSTROM: Wikstrom{},
+}
+
+// Prover returns the shuffle proof system registered under a name. An empty
+// name selects the Neff shuffle.
+func Prover(name string) (ShuffleProver, error) {
+	if name == "" {
+		name = NEFF
+	}
+
+	prover, found := provers[name]
+	if !found {
+		return nil, ERR_UNKNOWN_PROVER
+	}
+	return prover, nil
+}
+
+// fresh returns the factors if there is one per pair and new ones otherwise.
+func fresh(public kyber.Point, factors []*Factor, n int) []*Factor {
+	if len(factors) != n {
+		return Factors(public, n)
+	}
+	return factors
+}
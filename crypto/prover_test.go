@@ -0,0 +1,114 @@
+package crypto
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dedis/kyber"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProver(t *testing.T) {
+	prover, _ := Prover("")
+	assert.Equal(t, Neff{}, prover)
+
+	prover, _ = Prover(WIKSTROM)
+	assert.Equal(t, Wikstrom{}, prover)
+
+	_, err := Prover("bayer-groth")
+	assert.Equal(t, ERR_UNKNOWN_PROVER, err)
+}
+
+func TestNeff(t *testing.T) {
+	testProver(t, Neff{})
+}
+
+func TestWikstrom(t *testing.T) {
+	testProver(t, Wikstrom{})
+
+	_, X := RandomKeyPair()
+	x, y := genPairs(X, 3)
+	v, w, proof, _ := Wikstrom{}.Shuffle(X, x, y, nil)
+	assert.Equal(t, ERR_INVALID_PROOF, Wikstrom{}.Verify(proof[1:], X, x, y, v, w))
+	assert.Equal(t, ERR_INVALID_PROOF, Wikstrom{}.Verify(proof, X, x[1:], y[1:], v[1:], w[1:]))
+
+	_, _, _, err := Wikstrom{}.Shuffle(X, nil, nil, nil)
+	assert.Equal(t, ERR_EMPTY_SHUFFLE, err)
+}
+
+func testProver(t *testing.T, prover ShuffleProver) {
+	_, X := RandomKeyPair()
+	for _, n := range []int{1, 2, 10} {
+		x, y := genPairs(X, n)
+		v, w, proof, _ := prover.Shuffle(X, x, y, nil)
+		assert.Nil(t, prover.Verify(proof, X, x, y, v, w))
+
+		_, Y := RandomKeyPair()
+		assert.NotNil(t, prover.Verify(proof, Y, x, y, v, w))
+
+		w[0] = Suite.Point().Add(w[0], Base)
+		assert.NotNil(t, prover.Verify(proof, X, x, y, v, w))
+
+		v, w, proof, _ = prover.Shuffle(X, x, y, Factors(X, n))
+		assert.Nil(t, prover.Verify(proof, X, x, y, v, w))
+	}
+}
+
+func BenchmarkShuffle(b *testing.B) {
+	benchmark(b, func(prover ShuffleProver, X kyber.Point, x, y []kyber.Point) {
+		prover.Shuffle(X, x, y, nil)
+	})
+}
+
+// BenchmarkShuffle_Precomputed measures the latency of a shuffle once the
+// re-encryption factors have been precomputed.
+func BenchmarkShuffle_Precomputed(b *testing.B) {
+	_, X := RandomKeyPair()
+	for _, name := range []string{NEFF, WIKSTROM} {
+		prover, _ := Prover(name)
+		for _, n := range []int{10, 100, 1000} {
+			x, y := genPairs(X, n)
+			b.Run(fmt.Sprintf("%s/%d", name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					factors := Factors(X, n)
+					b.StartTimer()
+					prover.Shuffle(X, x, y, factors)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	benchmark(b, func(prover ShuffleProver, X kyber.Point, x, y []kyber.Point) {
+		v, w, proof, _ := prover.Shuffle(X, x, y, nil)
+		prover.Verify(proof, X, x, y, v, w)
+	})
+}
+
+// benchmark runs an operation for both proof systems and several box sizes.
+func benchmark(b *testing.B, op func(ShuffleProver, kyber.Point, []kyber.Point, []kyber.Point)) {
+	_, X := RandomKeyPair()
+	for _, name := range []string{NEFF, WIKSTROM} {
+		prover, _ := Prover(name)
+		for _, n := range []int{10, 100, 1000} {
+			x, y := genPairs(X, n)
+			b.Run(fmt.Sprintf("%s/%d", name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					op(prover, X, x, y)
+				}
+			})
+		}
+	}
+}
+
+// genPairs encrypts n random messages.
+func genPairs(X kyber.Point, n int) ([]kyber.Point, []kyber.Point) {
+	x, y := make([]kyber.Point, n), make([]kyber.Point, n)
+	for i := range x {
+		x[i], y[i] = Encrypt(X, []byte{byte(i)})
+	}
+	return x, y
+}
@@ -0,0 +1,332 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/util/random"
+)
+
+var ERR_INVALID_PROOF = errors.New("Invalid shuffle proof")
+
+// Wikstrom is the commitment-consistent proof of a shuffle by Terelius and
+// Wikström as used in Verificatum, made non-interactive with the Fiat-Shamir
+// heuristic. The notation follows Haenni et al., Pseudo-Code Algorithms for
+// Verifiable Re-Encryption Mix-Nets, 2017, where a ciphertext (a, b) is the
+// pair (y, x) of this package.
+type Wikstrom struct{}
+
+// wikstromProof is the transcript of a Terelius-Wikström proof.
+type wikstromProof struct {
+	c    []kyber.Point // c is the commitment to the permutation.
+	chat []kyber.Point // chat is the commitment chain.
+
+	// Commitments of the sigma protocol.
+	t1, t2, t3, t41, t42 kyber.Point
+	that                 []kyber.Point
+
+	// Responses of the sigma protocol.
+	s1, s2, s3, s4 kyber.Scalar
+	shat, sprime   []kyber.Scalar
+}
+
+// Shuffle permutes and re-encrypts the pairs and proves the shuffle.
+func (Wikstrom) Shuffle(public kyber.Point, x, y []kyber.Point, factors []*Factor) (
+	[]kyber.Point, []kyber.Point, []byte, error) {
+
+	n := len(x)
+	if n == 0 || len(y) != n {
+		return nil, nil, nil, ERR_EMPTY_SHUFFLE
+	}
+	h0, h := generators(n)
+	psi := permutation(n)
+
+	// Output i is the re-encryption of input psi[i] with factor i.
+	factors = fresh(public, factors, n)
+	rt := make([]kyber.Scalar, n)
+	v, w := make([]kyber.Point, n), make([]kyber.Point, n)
+	for i, factor := range factors {
+		rt[i] = factor.R
+		v[i] = Suite.Point().Add(x[psi[i]], factor.Alpha)
+		w[i] = Suite.Point().Add(y[psi[i]], factor.Beta)
+	}
+
+	p := &wikstromProof{c: make([]kyber.Point, n), chat: make([]kyber.Point, n)}
+	r := scalars(n)
+	for i := range psi {
+		p.c[psi[i]] = Suite.Point().Add(Suite.Point().Mul(r[psi[i]], nil), h[i])
+	}
+
+	seed := digest(public, x, y, v, w, p.c)
+	u := challenges(seed, n)
+	ut := make([]kyber.Scalar, n)
+	for i := range ut {
+		ut[i] = u[psi[i]]
+	}
+
+	rhat, prev := scalars(n), h0
+	for i := range p.chat {
+		p.chat[i] = Suite.Point().Add(Suite.Point().Mul(rhat[i], nil), Suite.Point().Mul(ut[i], prev))
+		prev = p.chat[i]
+	}
+
+	omega, what, wprime := scalars(4), scalars(n), scalars(n)
+	p.t1 = Suite.Point().Mul(omega[0], nil)
+	p.t2 = Suite.Point().Mul(omega[1], nil)
+	p.t3 = Suite.Point().Add(Suite.Point().Mul(omega[2], nil), sum(h, wprime))
+	p.t41 = Suite.Point().Sub(sum(w, wprime), Suite.Point().Mul(omega[3], public))
+	p.t42 = Suite.Point().Sub(sum(v, wprime), Suite.Point().Mul(omega[3], nil))
+	p.that, prev = make([]kyber.Point, n), h0
+	for i := range p.that {
+		p.that[i] = Suite.Point().Add(Suite.Point().Mul(what[i], nil), Suite.Point().Mul(wprime[i], prev))
+		prev = p.chat[i]
+	}
+
+	ch := p.challenge(seed)
+
+	// vv[i] is the product of the permuted challenges after position i.
+	vv := make([]kyber.Scalar, n)
+	vv[n-1] = Suite.Scalar().One()
+	for i := n - 1; i > 0; i-- {
+		vv[i-1] = Suite.Scalar().Mul(ut[i], vv[i])
+	}
+
+	rbar := Suite.Scalar().Zero()
+	for _, ri := range r {
+		rbar.Add(rbar, ri)
+	}
+	p.s1 = respond(omega[0], ch, rbar)
+	p.s2 = respond(omega[1], ch, inner(rhat, vv))
+	p.s3 = respond(omega[2], ch, inner(r, u))
+	p.s4 = respond(omega[3], ch, inner(rt, ut))
+	p.shat, p.sprime = make([]kyber.Scalar, n), make([]kyber.Scalar, n)
+	for i := range p.shat {
+		p.shat[i] = respond(what[i], ch, rhat[i])
+		p.sprime[i] = respond(wprime[i], ch, ut[i])
+	}
+
+	proof, err := p.marshal()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return v, w, proof, nil
+}
+
+// Verify checks the Terelius-Wikström proof of a shuffle.
+func (Wikstrom) Verify(proof []byte, public kyber.Point, x, y, v, w []kyber.Point) error {
+	n := len(x)
+	if n == 0 || len(y) != n || len(v) != n || len(w) != n {
+		return ERR_INVALID_PROOF
+	}
+
+	p, err := unmarshalWikstrom(proof, n)
+	if err != nil {
+		return ERR_INVALID_PROOF
+	}
+	h0, h := generators(n)
+
+	seed := digest(public, x, y, v, w, p.c)
+	u := challenges(seed, n)
+	ch := p.challenge(seed)
+
+	uprod := Suite.Scalar().One()
+	for _, ui := range u {
+		uprod.Mul(uprod, ui)
+	}
+
+	cbar := Suite.Point().Sub(sum(p.c, ones(n)), sum(h, ones(n)))
+	chat := Suite.Point().Sub(p.chat[n-1], Suite.Point().Mul(uprod, h0))
+
+	t1 := Suite.Point().Add(Suite.Point().Mul(ch, cbar), Suite.Point().Mul(p.s1, nil))
+	t2 := Suite.Point().Add(Suite.Point().Mul(ch, chat), Suite.Point().Mul(p.s2, nil))
+	t3 := Suite.Point().Add(Suite.Point().Mul(ch, sum(p.c, u)), Suite.Point().Mul(p.s3, nil))
+	t3.Add(t3, sum(h, p.sprime))
+	t41 := Suite.Point().Sub(Suite.Point().Mul(ch, sum(y, u)), Suite.Point().Mul(p.s4, public))
+	t41.Add(t41, sum(w, p.sprime))
+	t42 := Suite.Point().Sub(Suite.Point().Mul(ch, sum(x, u)), Suite.Point().Mul(p.s4, nil))
+	t42.Add(t42, sum(v, p.sprime))
+
+	if !t1.Equal(p.t1) || !t2.Equal(p.t2) || !t3.Equal(p.t3) || !t41.Equal(p.t41) ||
+		!t42.Equal(p.t42) {
+		return ERR_INVALID_PROOF
+	}
+
+	prev := h0
+	for i := range p.that {
+		that := Suite.Point().Add(Suite.Point().Mul(ch, p.chat[i]), Suite.Point().Mul(p.shat[i], nil))
+		that.Add(that, Suite.Point().Mul(p.sprime[i], prev))
+		if !that.Equal(p.that[i]) {
+			return ERR_INVALID_PROOF
+		}
+		prev = p.chat[i]
+	}
+	return nil
+}
+
+// challenge derives the challenge of the sigma protocol from the statement
+// seed, the commitment chain and the commitments.
+func (p *wikstromProof) challenge(seed []byte) kyber.Scalar {
+	hash := Suite.Hash()
+	hash.Write(seed)
+	points := append(append([]kyber.Point{}, p.chat...), p.t1, p.t2, p.t3, p.t41, p.t42)
+	for _, point := range append(points, p.that...) {
+		point.MarshalTo(hash)
+	}
+	return Suite.Scalar().Pick(Suite.XOF(hash.Sum(nil)))
+}
+
+// marshal encodes the proof as a sequence of points followed by scalars.
+func (p *wikstromProof) marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	points := append(append([]kyber.Point{}, p.c...), p.chat...)
+	points = append(append(points, p.t1, p.t2, p.t3, p.t41, p.t42), p.that...)
+	for _, point := range points {
+		if _, err := point.MarshalTo(&buf); err != nil {
+			return nil, err
+		}
+	}
+
+	scalars := append([]kyber.Scalar{p.s1, p.s2, p.s3, p.s4}, p.shat...)
+	for _, scalar := range append(scalars, p.sprime...) {
+		if _, err := scalar.MarshalTo(&buf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalWikstrom decodes the proof of a shuffle of n pairs.
+func unmarshalWikstrom(data []byte, n int) (*wikstromProof, error) {
+	if len(data) != (3*n+5)*Suite.PointLen()+(2*n+4)*Suite.ScalarLen() {
+		return nil, ERR_INVALID_PROOF
+	}
+
+	reader := bytes.NewReader(data)
+	points := make([]kyber.Point, 3*n+5)
+	for i := range points {
+		points[i] = Suite.Point()
+		if _, err := points[i].UnmarshalFrom(reader); err != nil {
+			return nil, err
+		}
+	}
+	scalars := make([]kyber.Scalar, 2*n+4)
+	for i := range scalars {
+		scalars[i] = Suite.Scalar()
+		if _, err := scalars[i].UnmarshalFrom(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	return &wikstromProof{
+		c:      points[:n],
+		chat:   points[n : 2*n],
+		t1:     points[2*n],
+		t2:     points[2*n+1],
+		t3:     points[2*n+2],
+		t41:    points[2*n+3],
+		t42:    points[2*n+4],
+		that:   points[2*n+5:],
+		s1:     scalars[0],
+		s2:     scalars[1],
+		s3:     scalars[2],
+		s4:     scalars[3],
+		shat:   scalars[4 : n+4],
+		sprime: scalars[n+4:],
+	}, nil
+}
+
+// generators derives n+1 independent generators with unknown discrete
+// logarithms by hashing into the group.
+func generators(n int) (kyber.Point, []kyber.Point) {
+	label := []byte("nevv wikstrom generator")
+	h := make([]kyber.Point, n)
+	for i := range h {
+		h[i] = Suite.Point().Pick(Suite.XOF(append(append([]byte{}, label...), index(i)...)))
+	}
+	return Suite.Point().Pick(Suite.XOF(label)), h
+}
+
+// digest hashes the statement of the shuffle and the permutation commitment.
+func digest(public kyber.Point, x, y, v, w, c []kyber.Point) []byte {
+	hash := Suite.Hash()
+	public.MarshalTo(hash)
+	for _, list := range [][]kyber.Point{x, y, v, w, c} {
+		for _, point := range list {
+			point.MarshalTo(hash)
+		}
+	}
+	return hash.Sum(nil)
+}
+
+// challenges derives n challenges from the statement seed.
+func challenges(seed []byte, n int) []kyber.Scalar {
+	u := make([]kyber.Scalar, n)
+	for i := range u {
+		u[i] = Suite.Scalar().Pick(Suite.XOF(append(append([]byte{}, seed...), index(i)...)))
+	}
+	return u
+}
+
+// permutation returns a uniformly random permutation of n elements.
+func permutation(n int) []int {
+	psi := make([]int, n)
+	for i := range psi {
+		psi[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j := random.Int(big.NewInt(int64(i+1)), Stream).Int64()
+		psi[i], psi[j] = psi[j], psi[i]
+	}
+	return psi
+}
+
+// scalars picks n random scalars.
+func scalars(n int) []kyber.Scalar {
+	s := make([]kyber.Scalar, n)
+	for i := range s {
+		s[i] = Suite.Scalar().Pick(Stream)
+	}
+	return s
+}
+
+// ones returns n scalars equal to one.
+func ones(n int) []kyber.Scalar {
+	s := make([]kyber.Scalar, n)
+	for i := range s {
+		s[i] = Suite.Scalar().One()
+	}
+	return s
+}
+
+// sum computes the linear combination of points with scalars.
+func sum(points []kyber.Point, scalars []kyber.Scalar) kyber.Point {
+	total := Suite.Point().Null()
+	for i := range points {
+		total.Add(total, Suite.Point().Mul(scalars[i], points[i]))
+	}
+	return total
+}
+
+// inner computes the inner product of two lists of scalars.
+func inner(a, b []kyber.Scalar) kyber.Scalar {
+	total := Suite.Scalar().Zero()
+	for i := range a {
+		total.Add(total, Suite.Scalar().Mul(a[i], b[i]))
+	}
+	return total
+}
+
+// respond computes the response omega - c * secret.
+func respond(omega, c, secret kyber.Scalar) kyber.Scalar {
+	return Suite.Scalar().Sub(omega, Suite.Scalar().Mul(c, secret))
+}
+
+// index encodes a position as four big-endian bytes.
+func index(i int) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, uint32(i))
+	return buf
+}
@@ -1,8 +1,14 @@
 package decrypt
 
 import (
+	"errors"
+	"time"
+
 	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/proof/dleq"
+	"github.com/dedis/kyber/share"
 	"github.com/dedis/onet"
+	"github.com/dedis/onet/log"
 	"github.com/dedis/onet/network"
 
 	"github.com/qantik/nevv/chains"
@@ -13,86 +19,265 @@ import (
 // Name is the protocol identifier string.
 const Name = "decrypt"
 
+var (
+	ERR_NOT_ENOUGH_PARTIALS = errors.New("Not enough valid partials")
+	ERR_INVALID_PARTIAL     = errors.New("Invalid partial decryption")
+	ERR_FOREIGN_PARTIAL     = errors.New("Partial is not signed by the replying node")
+	ERR_DUPLICATE_PARTIAL   = errors.New("Partial of this node is already stored")
+)
+
 // Protocol is the core structure of the protocol.
 type Protocol struct {
 	*onet.TreeNodeInstance
 
-	Secret   *dkg.SharedSecret // Secret is the private key share from the DKG.
-	Election *chains.Election  // Election to be decrypted.
-	Finished chan bool         // Flag to signal protocol termination.
+	Secret    *dkg.SharedSecret // Secret is the private key share from the DKG.
+	Election  *chains.Election  // Election to be decrypted.
+	Threshold int               // Threshold of valid partials to terminate.
+	Finished  chan bool         // Flag to signal protocol termination.
+
+	valid   int          // valid is the number of stored verified partials.
+	counted map[int]bool // counted marks the share indices of the verified partials.
 }
 
 func init() {
-	network.RegisterMessages(Prompt{}, Terminate{})
+	network.RegisterMessages(Prompt{}, Reply{})
 	onet.GlobalProtocolRegister(Name, New)
 }
 
 // New initializes the protocol object and registers all the handlers.
 func New(node *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
-	protocol := &Protocol{TreeNodeInstance: node, Finished: make(chan bool)}
-	protocol.RegisterHandlers(protocol.HandlePrompt, protocol.HandleTerminate)
+	protocol := &Protocol{
+		TreeNodeInstance: node,
+		Threshold:        dkg.Threshold(len(node.Roster().List)),
+		Finished:         make(chan bool, 1),
+		counted:          make(map[int]bool),
+	}
+	protocol.RegisterHandlers(protocol.HandlePrompt, protocol.HandleReply)
 	return protocol, nil
 }
 
-// Start is called on the root node prompting it to send itself a Prompt message.
+// Start stores the partial decryption of the root and prompts all other nodes.
+// Unreachable nodes are skipped.
 func (p *Protocol) Start() error {
-	return p.HandlePrompt(MessagePrompt{p.TreeNode(), Prompt{}})
+	partial, err := p.decrypt()
+	if err != nil {
+		return err
+	}
+	if err = p.store(p.TreeNode(), partial); err != nil {
+		return err
+	}
+
+	for _, child := range p.Children() {
+		if err := p.SendTo(child, &Prompt{}); err != nil {
+			log.Lvl2(p.Name(), "failed to prompt", child.Name(), err)
+		}
+	}
+	return nil
 }
 
-// HandlePrompt retrieves the mixes, verifies them and performs a partial decryption
-// on the last mix before appending it to the election skipchain.
+// HandlePrompt performs a partial decryption and sends it back to the root.
 func (p *Protocol) HandlePrompt(prompt MessagePrompt) error {
-	box, err := p.Election.Box()
+	partial, err := p.decrypt()
 	if err != nil {
 		return err
 	}
+	return p.SendToParent(&Reply{Partial: partial})
+}
+
+// HandleReply appends a received partial to the election skipchain.
+func (p *Protocol) HandleReply(reply MessageReply) error {
+	return p.store(reply.TreeNode, reply.Partial)
+}
+
+// decrypt retrieves the mixes, verifies them and performs a partial decryption
+// on the last mix. The partial is flagged if a mix could not be verified.
+func (p *Protocol) decrypt() (*chains.Partial, error) {
+	start := time.Now()
+
+	box, err := p.Election.Box()
+	if err != nil {
+		return nil, err
+	}
 	mixes, err := p.Election.Mixes()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	prover, err := p.Election.Prover()
+	if err != nil {
+		return nil, err
+	}
+
+	if !Verify(prover, p.Election.Key, box, mixes) {
+		partial := &chains.Partial{Flag: true, Index: p.Secret.Index, Node: p.Name()}
+		partial.Start, partial.Duration = start.UnixNano(), time.Since(start).Nanoseconds()
+		return partial, partial.Sign(p.Election.ID, p.Private())
 	}
 
-	var partial *chains.Partial
-	if !Verify(p.Election.Key, box, mixes) {
-		partial = &chains.Partial{Flag: true, Node: p.Name()}
-	} else {
-		last := mixes[len(mixes)-1].Ballots
-		points := make([]kyber.Point, len(box.Ballots))
-		for i := range points {
-			points[i] = crypto.Decrypt(p.Secret.V, last[i].Alpha, last[i].Beta)
+	partial, err := decryptMix(p.Secret, mixes[len(mixes)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	partial.Node = p.Name()
+	partial.Start, partial.Duration = start.UnixNano(), time.Since(start).Nanoseconds()
+	return partial, partial.Sign(p.Election.ID, p.Private())
+}
+
+// decryptMix partially decrypts the ballots of a mix with a secret share and
+// proves the correctness of each decryption.
+func decryptMix(secret *dkg.SharedSecret, mix *chains.Mix) (*chains.Partial, error) {
+	points := make([]kyber.Point, len(mix.Ballots))
+	proofs := make([]*dleq.Proof, len(mix.Ballots))
+	for i, ballot := range mix.Ballots {
+		var err error
+		points[i], proofs[i], err = crypto.ProveDecryption(secret.V, ballot.Alpha, ballot.Beta)
+		if err != nil {
+			return nil, err
 		}
-		partial = &chains.Partial{Points: points, Flag: false, Node: p.Name()}
 	}
 
-	if err = p.Election.Store(partial); err != nil {
+	return &chains.Partial{
+		Points: points,
+		Proofs: proofs,
+		Index:  secret.Index,
+		Public: secret.PublicShare(secret.Index),
+	}, nil
+}
+
+// store appends the partial of a node to the election skipchain and concludes
+// the protocol once the threshold of valid partials has been reached. Only
+// unflagged partials of distinct shares whose proofs verify against the DKG
+// transcript count towards the threshold.
+func (p *Protocol) store(node *onet.TreeNode, partial *chains.Partial) error {
+	if err := p.admit(node, partial); err != nil {
+		return err
+	} else if err := p.Election.Store(partial); err != nil {
 		return err
 	}
 
-	if p.IsLeaf() {
-		return p.SendTo(p.Root(), &Terminate{})
+	if err := p.check(partial); err != nil {
+		log.Lvl2(p.Name(), "not counting partial of", partial.Node, err)
+		return nil
+	}
+	p.counted[partial.Index] = true
+	p.valid++
+	if p.valid == p.Threshold {
+		p.Finished <- true
 	}
-	return p.SendToChildren(&Prompt{})
+	return nil
 }
 
-// HandleTerminate concludes to the protocol.
-func (p *Protocol) HandleTerminate(terminates []MessageTerminate) error {
-	p.Finished <- true
+// admit makes sure that a partial is signed by the node that sent it and that
+// no partial of this node is stored yet, as a second one corrupts the election.
+func (p *Protocol) admit(node *onet.TreeNode, partial *chains.Partial) error {
+	if partial == nil || partial.Signer == nil || !partial.Signer.Equal(node.ServerIdentity.Public) {
+		return ERR_FOREIGN_PARTIAL
+	} else if err := partial.Verify(p.Election.ID); err != nil {
+		return err
+	}
+
+	partials, err := p.Election.Partials()
+	if err != nil {
+		return err
+	}
+	for _, stored := range partials {
+		if stored.Signer != nil && stored.Signer.Equal(partial.Signer) {
+			return ERR_DUPLICATE_PARTIAL
+		}
+	}
 	return nil
 }
 
-// Verify iteratively checks the integrity of each mix.
-func Verify(key kyber.Point, box *chains.Box, mixes []*chains.Mix) bool {
-	x, y := chains.Split(box.Ballots)
-	v, w := chains.Split(mixes[0].Ballots)
-	if crypto.Verify(mixes[0].Proof, key, x, y, v, w) != nil {
-		return false
+// check verifies an unflagged partial of a share not counted yet against the
+// public share of its index and the last mix.
+func (p *Protocol) check(partial *chains.Partial) error {
+	if partial.Flag || p.counted[partial.Index] {
+		return ERR_INVALID_PARTIAL
+	} else if partial.Index < 0 || partial.Index >= len(p.Election.Roster.List) {
+		return ERR_INVALID_PARTIAL
+	}
+
+	mixes, err := p.Election.Mixes()
+	if err != nil {
+		return err
+	} else if len(mixes) == 0 {
+		return ERR_INVALID_PARTIAL
+	}
+	public := p.Election.Poly().Eval(partial.Index).V
+	return VerifyPartial(public, mixes[len(mixes)-1], partial)
+}
+
+// Reconstruct verifies the decryption proofs of the partials against the
+// public shares of the polynomial and recovers the plaintexts using Lagrange
+// interpolation over the share indices of the first t distinct valid partials.
+// Partials with invalid proofs are returned as rejected.
+func Reconstruct(poly *share.PubPoly, mix *chains.Mix, partials []*chains.Partial, t, n int) (
+	[]kyber.Point, []*chains.Partial, error) {
+
+	valid, rejected := make([]*chains.Partial, 0), make([]*chains.Partial, 0)
+	seen := make(map[int]bool)
+	for _, partial := range partials {
+		if partial.Flag || seen[partial.Index] {
+			continue
+		} else if partial.Index < 0 || partial.Index >= n {
+			rejected = append(rejected, partial)
+			continue
+		}
+
+		if VerifyPartial(poly.Eval(partial.Index).V, mix, partial) != nil {
+			rejected = append(rejected, partial)
+			continue
+		}
+		seen[partial.Index] = true
+		valid = append(valid, partial)
+	}
+
+	if len(valid) < t {
+		return nil, rejected, ERR_NOT_ENOUGH_PARTIALS
 	}
 
-	for i := 0; i < len(mixes)-1; i++ {
-		x, y = chains.Split(mixes[i].Ballots)
-		v, w = chains.Split(mixes[i+1].Ballots)
-		if crypto.Verify(mixes[i+1].Proof, key, x, y, v, w) != nil {
+	points := make([]kyber.Point, len(mix.Ballots))
+	for i := range points {
+		shares := make([]*share.PubShare, len(valid))
+		for j, partial := range valid {
+			shares[j] = &share.PubShare{I: partial.Index, V: partial.Points[i]}
+		}
+
+		message, err := share.RecoverCommit(crypto.Suite, shares, t, n)
+		if err != nil {
+			return nil, rejected, err
+		}
+		points[i] = message
+	}
+	return points, rejected, nil
+}
+
+// VerifyPartial checks the decryption proof of each point of a partial against
+// the public share of its creator and the ballots of the decrypted mix.
+func VerifyPartial(public kyber.Point, mix *chains.Mix, partial *chains.Partial) error {
+	if len(partial.Points) != len(mix.Ballots) || partial.Complete() != nil {
+		return ERR_INVALID_PARTIAL
+	}
+
+	for i, ballot := range mix.Ballots {
+		err := crypto.VerifyDecryption(public, ballot.Alpha, ballot.Beta, partial.Points[i],
+			partial.Proofs[i])
+		if err != nil {
+			return ERR_INVALID_PARTIAL
+		}
+	}
+	return nil
+}
+
+// Verify iteratively checks the integrity of each mix.
+func Verify(prover crypto.ShuffleProver, key kyber.Point, box *chains.Box, mixes []*chains.Mix) bool {
+	ballots := box.Ballots
+	for _, mix := range mixes {
+		if chains.VerifyShuffle(prover, key, ballots, mix) != nil {
 			return false
 		}
+		ballots = mix.Ballots
 	}
 	return true
 }
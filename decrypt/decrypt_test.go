@@ -2,6 +2,7 @@ package decrypt
 
 import (
 	"errors"
+	"sort"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/qantik/nevv/chains"
 	"github.com/qantik/nevv/crypto"
 	"github.com/qantik/nevv/dkg"
+	"github.com/qantik/nevv/internal/testutil"
 )
 
 var serviceID onet.ServiceID
@@ -20,6 +22,7 @@ type service struct {
 	*onet.ServiceProcessor
 	secret   *dkg.SharedSecret
 	election *chains.Election
+	offline  bool
 }
 
 func init() {
@@ -32,6 +35,10 @@ func init() {
 func (s *service) NewProtocol(node *onet.TreeNodeInstance, conf *onet.GenericConfig) (
 	onet.ProtocolInstance, error) {
 
+	if s.offline {
+		return nil, errors.New("Node is offline")
+	}
+
 	switch node.ProtocolName() {
 	case Name:
 		instance, _ := New(node)
@@ -45,24 +52,31 @@ func (s *service) NewProtocol(node *onet.TreeNodeInstance, conf *onet.GenericCon
 }
 
 func TestProtocol(t *testing.T) {
-	for _, nodes := range []int{3} {
-		run(t, nodes)
+	for _, nodes := range []int{3, 5} {
+		run(t, nodes, 0)
 	}
 }
 
-func run(t *testing.T, n int) {
+func TestProtocol_Offline(t *testing.T) {
+	run(t, 4, 1)
+	run(t, 7, 2)
+}
+
+func run(t *testing.T, n, offline int) {
 	local := onet.NewLocalTest(crypto.Suite)
 	defer local.CloseAll()
 
-	nodes, roster, tree := local.GenBigTree(n, n, 1, true)
+	nodes, roster, _ := local.GenBigTree(n, n, 1, true)
+	tree := roster.GenerateNaryTreeWithRoot(n-1, roster.List[0])
 
 	election := &chains.Election{Roster: roster, Stage: chains.SHUFFLED}
-	dkgs := election.GenChain(n)
+	dkgs := election.GenChain(n, testutil.Keys(local, nodes)...)
 
 	services := local.GetServices(nodes, serviceID)
 	for i := range services {
 		services[i].(*service).secret, _ = dkg.NewSharedSecret(dkgs[i])
 		services[i].(*service).election = election
+		services[i].(*service).offline = i >= n-offline
 	}
 
 	instance, _ := services[0].(*service).CreateProtocol(Name, tree)
@@ -73,12 +87,117 @@ func run(t *testing.T, n int) {
 
 	select {
 	case <-protocol.Finished:
-		// partials, _ := election.Partials()
-		// for _, partial := range partials {
-		// 	fmt.Println(partial)
-		// 	assert.False(t, partial.Flag)
-		// }
+		partials, _ := election.Partials()
+		assert.True(t, len(partials) >= dkg.Threshold(n))
+		for _, partial := range partials {
+			assert.False(t, partial.Flag)
+		}
 	case <-time.After(5 * time.Second):
 		assert.True(t, false)
 	}
 }
+
+func TestReconstruct(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.DECRYPTED}
+	dkgs := election.GenChain(5, testutil.Keys(local, nodes)...)
+	secret, _ := dkg.NewSharedSecret(dkgs[0])
+
+	mixes, _ := election.Mixes()
+	partials, _ := election.Partials()
+	mix := mixes[len(mixes)-1]
+
+	_, _, err := Reconstruct(secret.Poly(), mix, partials[2:], 3, 4)
+	assert.Equal(t, ERR_NOT_ENOUGH_PARTIALS, err)
+
+	partials[0].Points[0] = partials[0].Points[1]
+	ordered := []*chains.Partial{partials[3], partials[0], partials[2], partials[1]}
+	points, rejected, _ := Reconstruct(secret.Poly(), mix, ordered, 3, 4)
+	assert.Equal(t, []*chains.Partial{partials[0]}, rejected)
+
+	messages := make([]int, len(points))
+	for i, point := range points {
+		data, _ := point.Data()
+		messages[i] = int(data[0])
+	}
+	sort.Ints(messages)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, messages)
+}
+
+func TestVerifyPartial(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.DECRYPTED}
+	dkgs := election.GenChain(3, testutil.Keys(local, nodes)...)
+	secret, _ := dkg.NewSharedSecret(dkgs[0])
+
+	mixes, _ := election.Mixes()
+	partials, _ := election.Partials()
+	mix := mixes[len(mixes)-1]
+
+	assert.Nil(t, VerifyPartial(secret.PublicShare(0), mix, partials[0]))
+	assert.Equal(t, ERR_INVALID_PARTIAL, VerifyPartial(secret.PublicShare(1), mix, partials[0]))
+
+	partials[0].Proofs = partials[0].Proofs[1:]
+	assert.Equal(t, ERR_INVALID_PARTIAL, VerifyPartial(secret.PublicShare(0), mix, partials[0]))
+}
+
+func TestProtocol_InvalidPartial(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	tree := roster.GenerateNaryTreeWithRoot(2, roster.List[0])
+	keys := testutil.Keys(local, nodes)
+
+	election := &chains.Election{Roster: roster, Stage: chains.SHUFFLED}
+	dkgs := election.GenChain(3, keys...)
+	mixes, _ := election.Mixes()
+
+	partials := make([]*chains.Partial, len(dkgs))
+	for i := range dkgs {
+		secret, _ := dkg.NewSharedSecret(dkgs[i])
+		partials[i], _ = decryptMix(secret, mixes[len(mixes)-1])
+		partials[i].Sign(election.ID, keys[i])
+	}
+	forged := *partials[0]
+	forged.Index = 1
+	forged.Sign(election.ID, keys[1])
+
+	instance, _ := local.GetServices(nodes, serviceID)[0].(*service).CreateProtocol(Name, tree)
+	protocol := instance.(*Protocol)
+	protocol.Election = election
+	protocol.Threshold = 2
+
+	node := make([]*onet.TreeNode, len(nodes))
+	for _, n := range tree.List() {
+		index, _ := roster.Search(n.ServerIdentity.ID)
+		node[index] = n
+	}
+
+	assert.Nil(t, protocol.store(node[1], &forged))
+	assert.Nil(t, protocol.store(node[0], partials[0]))
+	assert.Equal(t, ERR_DUPLICATE_PARTIAL, protocol.store(node[0], partials[0]))
+	assert.Equal(t, ERR_FOREIGN_PARTIAL, protocol.store(node[1], partials[2]))
+	assert.Equal(t, 1, protocol.valid)
+	select {
+	case <-protocol.Finished:
+		t.Fatal("Finished below the threshold")
+	default:
+	}
+
+	assert.Nil(t, protocol.store(node[2], partials[2]))
+	assert.True(t, <-protocol.Finished)
+
+	stored, _ := election.Partials()
+	assert.Equal(t, 3, len(stored))
+	e, _ := chains.FetchElection(roster, election.ID)
+	assert.Equal(t, chains.DECRYPTED, int(e.Stage))
+}
@@ -1,18 +1,23 @@
 /*
 Package decrypt implements the decryption protocol.
 
-Each participating node begins with verifying the integrity of each mix. If
-If all mixes are correct a partial decryption of the last mix is performed using
-the node's shared secret from the DKG. The result is the appended to the election
-skipchain before prompting the next node. If at least one mix can no be verified
-the node won't create a decryption but appends a flag indicating the failure
-on the skipchain. The leaf node notifies the root upon completing its turn, which
-terminates the protocol.
+The root prompts all other nodes at once. Each participating node begins with
+verifying the integrity of each mix. If all mixes are correct a partial
+decryption of the last mix is performed using the node's shared secret from
+the DKG. The result is sent back to the root, which appends it to the election
+skipchain. If at least one mix can not be verified the node won't create a
+decryption but returns a flag indicating the failure. The protocol terminates
+as soon as the root has stored a threshold of valid partials, hence offline
+nodes do not prevent the decryption of an election. A partial only counts as
+valid once its proofs verify against the public share of its DKG index. The
+root only stores partials signed by the replying node and at most one per node.
 
 Schema:
 
-        [Prompt]            [Prompt]            [Prompt]         [Terminate]
-  Root ------------> Node1 ------------> Node2 --> ... --> Leaf ------------> Root
+                  [Prompt]
+        Root ----------------> Node1, Node2, ..., Leaf
+             <----------------
+                  [Reply]
 
 The protocol can only be started by the election's creator and is non-repeatable.
 */
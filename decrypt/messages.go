@@ -2,10 +2,12 @@ package decrypt
 
 import (
 	"github.com/dedis/onet"
+
+	"github.com/qantik/nevv/chains"
 )
 
-// Prompt is sent from node to node prompting the receiver to perform
-// their respective partial decryption of the last mix.
+// Prompt is sent from the root to all other nodes prompting the receivers
+// to perform their respective partial decryption of the last mix.
 type Prompt struct{}
 
 // MessagePrompt is a wrapper around Prompt.
@@ -14,12 +16,13 @@ type MessagePrompt struct {
 	Prompt
 }
 
-// Terminate is sent by the leaf node to the root node upon completion of
-// the last partial decryption, which terminates the protocol.
-type Terminate struct{}
+// Reply is sent back to the root carrying the partial decryption of a node.
+type Reply struct {
+	Partial *chains.Partial
+}
 
-// MessageTerminate is a wrapper around Terminate.
-type MessageTerminate struct {
+// MessageReply is a wrapper around Reply.
+type MessageReply struct {
 	*onet.TreeNode
-	Terminate
+	Reply
 }
@@ -0,0 +1,27 @@
+package decrypt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/dkg"
+)
+
+// Simulate performs an offline version of the decryption protocol in which
+// every secret share partially decrypts the given mix.
+func Simulate(secrets []*dkg.SharedSecret, mix *chains.Mix) ([]*chains.Partial, error) {
+	partials := make([]*chains.Partial, len(secrets))
+	for i, secret := range secrets {
+		start := time.Now()
+		partial, err := decryptMix(secret, mix)
+		if err != nil {
+			return nil, err
+		}
+
+		partial.Node = fmt.Sprintf("node-%d", i)
+		partial.Start, partial.Duration = start.UnixNano(), time.Since(start).Nanoseconds()
+		partials[i] = partial
+	}
+	return partials, nil
+}
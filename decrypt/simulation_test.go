@@ -0,0 +1,41 @@
+package decrypt
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/dkg"
+)
+
+func TestSimulate(t *testing.T) {
+	dkgs, _ := dkg.Simulate(4, 3)
+	secrets := make([]*dkg.SharedSecret, len(dkgs))
+	for i := range dkgs {
+		secrets[i], _ = dkg.NewSharedSecret(dkgs[i])
+	}
+
+	mix := &chains.Mix{Ballots: make([]*chains.Ballot, 3)}
+	for i := range mix.Ballots {
+		alpha, beta := crypto.Encrypt(secrets[0].X, []byte{byte(i)})
+		mix.Ballots[i] = &chains.Ballot{Alpha: alpha, Beta: beta}
+	}
+
+	partials, _ := Simulate(secrets, mix)
+	assert.Equal(t, 4, len(partials))
+	for _, partial := range partials {
+		assert.Nil(t, VerifyPartial(partial.Public, mix, partial))
+	}
+
+	points, _, _ := Reconstruct(secrets[0].Poly(), mix, partials, 3, 4)
+	messages := make([]int, len(points))
+	for i, point := range points {
+		data, _ := point.Data()
+		messages[i] = int(data[0])
+	}
+	sort.Ints(messages)
+	assert.Equal(t, []int{0, 1, 2}, messages)
+}
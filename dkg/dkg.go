@@ -8,15 +8,23 @@ paper-draft about onchain-secrets (called BlockMage).
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/dedis/cothority"
 	"github.com/dedis/kyber"
-	dkg "github.com/dedis/kyber/share/dkg/rabin"
+	dkg "github.com/dedis/kyber/share/dkg/pedersen"
+	vss "github.com/dedis/kyber/share/vss/pedersen"
 	"github.com/dedis/kyber/util/key"
 	"github.com/dedis/onet"
 	"github.com/dedis/onet/log"
 )
 
+// Timeout is the default time after which missing deals, responses and
+// justifications count as complaints.
+const Timeout = 5 * time.Second
+
+var ERR_NOT_CERTIFIED = errors.New("Not enough dealers qualified for the threshold")
+
 func init() {
 	onet.GlobalProtocolRegister(Name, NewSetupDKG)
 }
@@ -26,21 +34,53 @@ type SetupDKG struct {
 	*onet.TreeNodeInstance
 	DKG       *dkg.DistKeyGenerator
 	Threshold uint32
+	Timeout   time.Duration
+
+	// Secret is the current share of the node when resharing, nil otherwise.
+	Secret *SharedSecret
+	// NewRoster is set at the root to reshare Secret to the nodes of the
+	// roster instead of generating a fresh key.
+	NewRoster *onet.Roster
+	// Endorse is called with the new share of a node once resharing is done
+	// and returns its signature of the outcome, if set.
+	Endorse func(*SharedSecret) ([]byte, error)
+	// Endorsements are the signatures collected by the root.
+	Endorsements []*Endorsement
 
 	nodes   []*onet.TreeNode
 	keypair *key.Pair
 	publics []kyber.Point
-	// Whether we started the `DKG.SecretCommits`
-	commit bool
-	Wait   bool
-	Done   chan bool
+	Wait    bool
+	Done    chan bool
+
+	// Number of received deals, responses and justifications, and of
+	// complaints against other dealers awaiting a justification.
+	deals, responses, justifications, complaints int
+	// Number of deals and responses this node is sent.
+	expectedDeals, expectedResponses int
+
+	olds      []kyber.Point // olds are the keys of the dealers by share index.
+	dealers   []uint32      // dealers are the share indices of the taking part dealers.
+	receivers []uint32      // receivers are the tree positions of the participants.
+	required  int           // required is the number of qualified dealers needed.
 
-	structStartDeal    chan structStartDeal
-	structDeal         chan structDeal
-	structResponse     chan structResponse
-	structSecretCommit chan structSecretCommit
-	structWaitSetup    chan structWaitSetup
-	structWaitReply    chan []structWaitReply
+	index                 int                   // index of this node among the participants.
+	oldIndex              int                   // oldIndex of this node among the dealers.
+	dealt                 map[uint32]bool       // dealt marks the dealers whose deal is processed.
+	complained            map[[2]uint32]bool    // complained marks the (dealer, verifier) complaints.
+	pendingResponses      []structResponse      // pendingResponses await the deal of their dealer.
+	pendingJustifications []structJustification // pendingJustifications await their complaint.
+
+	// tamper alters outgoing deals and responses to test misbehaving nodes.
+	tamper func(interface{})
+
+	structStartDeal     chan structStartDeal
+	structDeal          chan structDeal
+	structResponse      chan structResponse
+	structJustification chan structJustification
+	structWaitSetup     chan structWaitSetup
+	structWaitReply     chan []structWaitReply
+	structEndorsement   chan structEndorsement
 }
 
 // NewSetupDKG initialises the structure for use in one round
@@ -49,7 +89,8 @@ func NewSetupDKG(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
 		TreeNodeInstance: n,
 		keypair:          key.NewKeyPair(cothority.Suite),
 		Done:             make(chan bool, 1),
-		Threshold:        uint32(len(n.Roster().List) - (len(n.Roster().List)-1)/3),
+		Threshold:        uint32(Threshold(len(n.Roster().List))),
+		Timeout:          Timeout,
 		nodes:            n.List(),
 	}
 
@@ -58,7 +99,7 @@ func NewSetupDKG(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
 		return nil, err
 	}
 	err = o.RegisterChannels(&o.structStartDeal, &o.structDeal, &o.structResponse,
-		&o.structSecretCommit, &o.structWaitSetup, &o.structWaitReply)
+		&o.structJustification, &o.structWaitSetup, &o.structWaitReply, &o.structEndorsement)
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +107,18 @@ func NewSetupDKG(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
 	return o, nil
 }
 
+// Threshold returns the default number of shares needed to recover the
+// secret, which tolerates up to a third of faulty nodes.
+func Threshold(n int) int {
+	return n - (n-1)/3
+}
+
+// ValidThreshold checks that a threshold requires a majority of the n nodes
+// without exceeding them.
+func ValidThreshold(t, n int) bool {
+	return t > n/2 && t <= n
+}
+
 // Start sends the Announce-message to all children
 func (o *SetupDKG) Start() error {
 	log.Lvl3("Starting Protocol")
@@ -77,34 +130,88 @@ func (o *SetupDKG) Start() error {
 	return nil
 }
 
-// Dispatch takes care for channel-messages that need to be treated in the correct order.
+// Dispatch processes the deals, responses and justifications in the order
+// they arrive until all of them have been received or the timeout expires.
+// Responses and justifications arriving ahead of the deal or complaint they
+// refer to are held back. Invalid messages are logged and dropped; after the
+// timeout every missing response counts as a complaint and dealers without a
+// certified deal are disqualified.
 func (o *SetupDKG) Dispatch() error {
 	err := o.allStartDeal(<-o.structStartDeal)
 	if err != nil {
 		return err
 	}
-	for _ = range o.publics[1:] {
-		err := o.allDeal(<-o.structDeal)
+
+	timeout := time.After(o.Timeout)
+	for !o.complete() {
+		err = nil
+		select {
+		case deal := <-o.structDeal:
+			err = o.allDeal(deal)
+		case response := <-o.structResponse:
+			o.responses++
+			o.pendingResponses = append(o.pendingResponses, response)
+		case justification := <-o.structJustification:
+			o.justifications++
+			o.pendingJustifications = append(o.pendingJustifications, justification)
+		case <-timeout:
+			log.Lvl2(o.Name(), "timeout with missing DKG messages")
+			o.DKG.SetTimeout()
+			return o.finish()
+		}
 		if err != nil {
-			return err
+			log.Warn(o.Name(), err)
 		}
+		o.flush()
 	}
-	l := len(o.publics)
-	for i := 0; i < l*(l-1); i++ {
-		// This is expected to return some errors, so do not stop on them.
-		err := o.allResponse(<-o.structResponse)
-		if err != nil && err.Error() != "vss: already existing response from same origin" &&
-			err.Error() != "dkg: can't give SecretCommits if deal not certified" {
-			return err
+	o.DKG.SetTimeout()
+	return o.finish()
+}
+
+// complete checks that all deals and responses of the other nodes have been
+// processed and every complaint against another dealer has been justified.
+func (o *SetupDKG) complete() bool {
+	return o.deals == o.expectedDeals && o.responses == o.expectedResponses &&
+		o.justifications >= o.complaints &&
+		len(o.pendingResponses) == 0 && len(o.pendingJustifications) == 0
+}
+
+// flush processes the held back responses and justifications that have
+// become processable.
+func (o *SetupDKG) flush() {
+	for progress := true; progress; {
+		progress = false
+		responses := o.pendingResponses[:0]
+		for _, response := range o.pendingResponses {
+			if !o.dealt[response.Response.Response.Index] {
+				responses = append(responses, response)
+				continue
+			}
+			progress = true
+			if err := o.allResponse(response); err != nil {
+				log.Warn(o.Name(), err)
+			}
 		}
-	}
-	for i := 0; i < l; i++ {
-		err := o.allSecretCommit(<-o.structSecretCommit)
-		if err != nil {
-			return err
+		o.pendingResponses = responses
+
+		justifications := o.pendingJustifications[:0]
+		for _, justification := range o.pendingJustifications {
+			j := justification.Justification.Justification
+			if !o.complained[[2]uint32{j.Index, j.Justification.Index}] {
+				justifications = append(justifications, justification)
+				continue
+			}
+			progress = true
+			if err := o.allJustification(justification); err != nil {
+				log.Warn(o.Name(), err)
+			}
 		}
+		o.pendingJustifications = justifications
 	}
+}
 
+// finish concludes the protocol once the qualified dealers are known.
+func (o *SetupDKG) finish() error {
 	if o.Wait {
 		if o.IsRoot() {
 			o.SendToChildren(&WaitSetup{})
@@ -115,13 +222,20 @@ func (o *SetupDKG) Dispatch() error {
 		}
 	}
 
-	if o.DKG.Finished() {
-		o.Done <- true
-		return nil
+	qual := o.DKG.QUAL()
+	log.Lvl3(o.Name(), "qualified dealers", qual)
+	if len(qual) < o.required {
+		log.Error(o.Name(), ERR_NOT_CERTIFIED)
+		return ERR_NOT_CERTIFIED
 	}
-	err = errors.New("protocol is finished but dkg is not")
-	log.Error(err)
-	return err
+	if o.Endorse != nil {
+		if err := o.endorse(); err != nil {
+			log.Error(o.Name(), err)
+			return err
+		}
+	}
+	o.Done <- true
+	return nil
 }
 
 // SharedSecret returns the necessary information for doing shared
@@ -134,12 +248,16 @@ func (o *SetupDKG) SharedSecret() (*SharedSecret, error) {
 func (o *SetupDKG) childInit(i structInit) error {
 	o.Wait = i.Wait
 	log.Lvl3(o.Name(), o.Wait)
-	return o.SendToParent(&InitReply{Public: o.keypair.Public})
+	return o.SendToParent(&InitReply{Public: o.keypair.Public, Index: o.shareIndex()})
 }
 
 // Root-node messages
 func (o *SetupDKG) rootStartDeal(replies []structInitReply) error {
 	log.Lvl3(o.Name(), replies)
+	if o.NewRoster != nil {
+		return o.rootReshare(replies)
+	}
+
 	o.publics[0] = o.keypair.Public
 	for _, r := range replies {
 		index, _ := o.Roster().Search(r.ServerIdentity.ID)
@@ -151,72 +269,165 @@ func (o *SetupDKG) rootStartDeal(replies []structInitReply) error {
 	return o.fullBroadcast(&StartDeal{
 		Publics:   o.publics,
 		Threshold: o.Threshold,
+		Receivers: span(len(o.publics)),
 	})
 }
 
 // Messages for both
 func (o *SetupDKG) allStartDeal(ssd structStartDeal) error {
 	log.Lvl3(o.Name(), "received startDeal from:", ssd.ServerIdentity)
+	o.publics, o.Threshold, o.receivers = ssd.Publics, ssd.Threshold, ssd.Receivers
+	o.olds, o.dealers, o.required = ssd.Olds, ssd.Dealers, int(ssd.OldThreshold)
+	if len(ssd.Olds) == 0 {
+		o.olds, o.dealers, o.required = o.publics, span(len(o.publics)), int(o.Threshold)
+	}
+	o.index, o.oldIndex = find(o.publics, o.keypair.Public), find(o.olds, o.keypair.Public)
+	o.expect()
+
 	var err error
-	o.DKG, err = dkg.NewDistKeyGenerator(cothority.Suite, o.keypair.Private,
-		ssd.Publics, int(ssd.Threshold))
+	if len(ssd.Olds) == 0 {
+		o.DKG, err = dkg.NewDistKeyGenerator(cothority.Suite, o.keypair.Private,
+			ssd.Publics, int(ssd.Threshold))
+	} else {
+		o.DKG, err = o.reshare(ssd.Commits)
+	}
 	if err != nil {
 		return err
+	} else if o.oldIndex < 0 {
+		return nil
 	}
-	o.publics = ssd.Publics
+
 	deals, err := o.DKG.Deals()
 	if err != nil {
 		return err
 	}
 	log.Lvl3(o.Name(), "sending out deals", len(deals))
 	for i, d := range deals {
-		if err := o.SendTo(o.nodes[i], &Deal{d}); err != nil {
+		if o.tamper != nil {
+			o.tamper(d)
+		}
+		if err := o.SendTo(o.nodes[o.receivers[i]], &Deal{d}); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// allDeal processes the deal of another dealer and broadcasts the response,
+// which is a complaint if the deal is invalid.
 func (o *SetupDKG) allDeal(sd structDeal) error {
 	log.Lvl3(o.Name(), sd.ServerIdentity)
+	o.deals++
 	resp, err := o.DKG.ProcessDeal(sd.Deal.Deal)
 	if err != nil {
-		log.Error(o.Name(), err)
 		return err
 	}
-	return o.fullBroadcast(&Response{resp})
+	o.dealt[resp.Index] = true
+	o.complaint(resp)
+	if o.tamper != nil {
+		o.tamper(resp)
+	}
+	return o.othersBroadcast(&Response{resp})
 }
 
+// allResponse processes the response of another node. A complaint against
+// the deal of this node is answered with a broadcast justification.
 func (o *SetupDKG) allResponse(resp structResponse) error {
 	log.Lvl3(o.Name(), resp.ServerIdentity)
 	just, err := o.DKG.ProcessResponse(resp.Response.Response)
 	if err != nil {
 		return err
 	}
+	o.complaint(resp.Response.Response)
 	if just != nil {
-		log.Warn(o.Name(), "Got a justification: ", just)
-		return nil
+		log.Lvl2(o.Name(), "justifying deal against complaint of", resp.ServerIdentity)
+		return o.multicast(&Justification{just}, o.receivers)
 	}
+	return nil
+}
 
-	commit, err := o.DKG.SecretCommits()
-	if !o.commit && err == nil {
-		o.commit = true
-		return o.fullBroadcast(&SecretCommit{commit})
+// allJustification processes the justification of a dealer. An invalid
+// justification disqualifies the dealer.
+func (o *SetupDKG) allJustification(just structJustification) error {
+	log.Lvl3(o.Name(), just.ServerIdentity)
+	j := just.Justification.Justification
+	if err := o.DKG.ProcessJustification(j); err != nil {
+		return fmt.Errorf("dealer %d disqualified: %v", j.Index, err)
 	}
-	return err
+	return nil
 }
 
-func (o *SetupDKG) allSecretCommit(comm structSecretCommit) error {
-	log.Lvl3(o.Name(), comm)
-	compl, err := o.DKG.ProcessSecretCommits(comm.SecretCommit.SecretCommit)
-	if err != nil {
-		log.Error(o.Name(), err)
-		return err
+// complaint records a complaint against another dealer, whose justification
+// is then awaited.
+func (o *SetupDKG) complaint(resp *dkg.Response) {
+	if o.index < 0 || resp.Response.Status != vss.StatusComplaint || int(resp.Index) == o.oldIndex {
+		return
 	}
-	if compl != nil {
-		log.Warn("got a complaint: ", compl)
+	o.complaints++
+	o.complained[[2]uint32{resp.Index, resp.Response.Index}] = true
+}
+
+// expect counts the deals and responses sent to this node. Every participant
+// is dealt a share by each dealer but itself, and broadcasts a response to
+// each of these deals. Nodes that only deal do not wait for any deal, and
+// they process responses without them.
+func (o *SetupDKG) expect() {
+	dealing := make(map[int]bool)
+	for _, d := range o.dealers {
+		if i := find(o.publics, o.olds[d]); i >= 0 {
+			dealing[i] = true
+		}
+	}
+
+	o.dealt = make(map[uint32]bool)
+	o.complained = make(map[[2]uint32]bool)
+	if o.index >= 0 {
+		o.expectedDeals = len(o.dealers)
+		if dealing[o.index] {
+			o.expectedDeals--
+			o.dealt[uint32(o.oldIndex)] = true
+		}
+	} else {
+		for _, d := range o.dealers {
+			o.dealt[d] = true
+		}
+	}
+
+	for i := range o.publics {
+		if i != o.index {
+			o.expectedResponses += len(o.dealers)
+			if dealing[i] {
+				o.expectedResponses--
+			}
+		}
 	}
-	return nil
+}
+
+// shareIndex returns the index of the current share of this node, or -1.
+func (o *SetupDKG) shareIndex() int {
+	if o.Secret == nil {
+		return -1
+	}
+	return o.Secret.Index
+}
+
+// span returns the indices from 0 to n-1.
+func span(n int) []uint32 {
+	indices := make([]uint32, n)
+	for i := range indices {
+		indices[i] = uint32(i)
+	}
+	return indices
+}
+
+// find returns the position of a key in a list, or -1.
+func find(keys []kyber.Point, key kyber.Point) int {
+	for i, k := range keys {
+		if k.Equal(key) {
+			return i
+		}
+	}
+	return -1
 }
 
 // Convenience functions
@@ -227,3 +438,24 @@ func (o *SetupDKG) fullBroadcast(msg interface{}) error {
 	}
 	return nil
 }
+
+// othersBroadcast sends a message to all nodes but this one.
+func (o *SetupDKG) othersBroadcast(msg interface{}) error {
+	return o.multicast(msg, span(len(o.nodes)))
+}
+
+// multicast sends a message to the nodes at the given tree positions but this
+// one.
+func (o *SetupDKG) multicast(msg interface{}, positions []uint32) error {
+	others := make([]*onet.TreeNode, 0, len(positions))
+	for _, i := range positions {
+		if node := o.nodes[i]; !node.ID.Equal(o.TreeNode().ID) {
+			others = append(others, node)
+		}
+	}
+	errs := o.Multicast(msg, others...)
+	if len(errs) != 0 {
+		return fmt.Errorf("multicast failed with error(s): %v", errs)
+	}
+	return nil
+}
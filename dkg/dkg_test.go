@@ -13,6 +13,11 @@ import (
 
 	"time"
 
+	"github.com/dedis/cothority"
+	"github.com/dedis/kyber"
+	dkg "github.com/dedis/kyber/share/dkg/pedersen"
+	vss "github.com/dedis/kyber/share/vss/pedersen"
+	"github.com/dedis/kyber/sign/schnorr"
 	"github.com/dedis/kyber/suites"
 	"github.com/dedis/onet"
 	"github.com/dedis/onet/log"
@@ -21,6 +26,52 @@ import (
 
 var tSuite = suites.MustFind("Ed25519")
 
+// Test protocols where the last node of the tree corrupts the signatures of
+// its deals or turns its approvals into complaints. All instances are
+// collected in the channel of their protocol.
+var tampered = map[string]chan *SetupDKG{
+	"SetupDKGBadDeal":        make(chan *SetupDKG, 10),
+	"SetupDKGFalseComplaint": make(chan *SetupDKG, 10),
+}
+
+func init() {
+	onet.GlobalProtocolRegister("SetupDKGBadDeal", newBadDeal)
+	onet.GlobalProtocolRegister("SetupDKGFalseComplaint", newFalseComplaint)
+}
+
+func newBadDeal(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
+	return newTampered(n, "SetupDKGBadDeal", func(o *SetupDKG, msg interface{}) {
+		if deal, ok := msg.(*dkg.Deal); ok {
+			deal.Deal.Signature[0] ^= 0xff
+		}
+	})
+}
+
+func newFalseComplaint(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
+	return newTampered(n, "SetupDKGFalseComplaint", func(o *SetupDKG, msg interface{}) {
+		if resp, ok := msg.(*dkg.Response); ok {
+			resp.Response.Status = vss.StatusComplaint
+			hash := resp.Response.Hash(cothority.Suite)
+			resp.Response.Signature, _ = schnorr.Sign(cothority.Suite, o.keypair.Private, hash)
+		}
+	})
+}
+
+func newTampered(n *onet.TreeNodeInstance, name string,
+	tamper func(*SetupDKG, interface{})) (onet.ProtocolInstance, error) {
+	instance, err := NewSetupDKG(n)
+	if err != nil {
+		return nil, err
+	}
+	o := instance.(*SetupDKG)
+	o.Timeout = 2 * time.Second
+	if n.Index() == len(n.List())-1 {
+		o.tamper = func(msg interface{}) { tamper(o, msg) }
+	}
+	tampered[name] <- o
+	return o, nil
+}
+
 func TestMain(m *testing.M) {
 	log.MainTest(m)
 }
@@ -57,3 +108,78 @@ func setupDKG(t *testing.T, nbrNodes int) {
 		t.Fatal("Didn't finish in time")
 	}
 }
+
+func TestSetupDKG_BadDeal(t *testing.T) {
+	protocols := runTampered(t, "SetupDKGBadDeal", 5)
+	for _, protocol := range protocols[:4] {
+		require.Equal(t, 4, len(protocol.DKG.QUAL()))
+		require.NotContains(t, protocol.DKG.QUAL(), 4)
+	}
+	requireSharedKey(t, protocols[:4])
+}
+
+func TestSetupDKG_FalseComplaint(t *testing.T) {
+	protocols := runTampered(t, "SetupDKGFalseComplaint", 5)
+	for _, protocol := range protocols {
+		require.Equal(t, 5, len(protocol.DKG.QUAL()))
+	}
+	requireSharedKey(t, protocols)
+}
+
+// runTampered runs a tampered protocol and returns the instances of all
+// nodes in tree order once they are done.
+func runTampered(t *testing.T, name string, nbrNodes int) []*SetupDKG {
+	local := onet.NewLocalTest(tSuite)
+	defer local.CloseAll()
+	_, _, tree := local.GenBigTree(nbrNodes, nbrNodes, nbrNodes, true)
+
+	pi, err := local.CreateProtocol(name, tree)
+	require.Nil(t, err)
+	pi.(*SetupDKG).Wait = true
+	require.Nil(t, pi.Start())
+
+	protocols := make([]*SetupDKG, nbrNodes)
+	for range protocols {
+		select {
+		case protocol := <-tampered[name]:
+			protocols[protocol.Index()] = protocol
+		case <-time.After(10 * time.Second):
+			t.Fatal("Didn't start in time")
+		}
+	}
+	for _, protocol := range protocols {
+		select {
+		case <-protocol.Done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Didn't finish in time")
+		}
+	}
+	return protocols
+}
+
+// requireSharedKey checks that the nodes agree on the collective key.
+func requireSharedKey(t *testing.T, protocols []*SetupDKG) {
+	var public kyber.Point
+	for _, protocol := range protocols {
+		secret, err := protocol.SharedSecret()
+		require.Nil(t, err)
+		if public != nil {
+			require.True(t, public.Equal(secret.X))
+		}
+		public = secret.X
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	require.Equal(t, 3, Threshold(3))
+	require.Equal(t, 3, Threshold(4))
+	require.Equal(t, 5, Threshold(7))
+}
+
+func TestValidThreshold(t *testing.T) {
+	require.True(t, ValidThreshold(Threshold(7), 7))
+	require.True(t, ValidThreshold(4, 7))
+	require.False(t, ValidThreshold(3, 7))
+	require.False(t, ValidThreshold(8, 7))
+	require.True(t, ValidThreshold(1, 1))
+}
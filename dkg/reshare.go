@@ -0,0 +1,142 @@
+package dkg
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dedis/cothority"
+	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/share"
+	dkg "github.com/dedis/kyber/share/dkg/pedersen"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/log"
+)
+
+// NameReshare can be used from other packages to refer to the resharing
+// protocol, which moves a shared secret to a new roster.
+const NameReshare = "ReshareDKG"
+
+var (
+	ERR_NO_SHARE         = errors.New("Resharing requires the root to hold a share")
+	ERR_UNKNOWN_RECEIVER = errors.New("New roster node is not part of the tree")
+)
+
+func init() {
+	onet.GlobalProtocolRegister(NameReshare, NewSetupDKG)
+}
+
+// rootReshare starts the resharing of the secret of the root. The current
+// share holders taking part deal new shares to the nodes of NewRoster, whose
+// new indices follow the roster order. Share holders missing from the tree
+// are replaced by random keys and never deal.
+func (o *SetupDKG) rootReshare(replies []structInitReply) error {
+	if o.Secret == nil {
+		return ERR_NO_SHARE
+	}
+
+	keys := make([]kyber.Point, len(o.nodes))
+	indices := make([]int, len(o.nodes))
+	keys[0], indices[0] = o.keypair.Public, o.Secret.Index
+	size := o.Secret.Index + 1
+	for _, r := range replies {
+		index, _ := o.Roster().Search(r.ServerIdentity.ID)
+		if index < 0 {
+			return errors.New("unknown serverIdentity")
+		}
+		keys[index], indices[index] = r.Public, r.Index
+		if r.Index >= size {
+			size = r.Index + 1
+		}
+	}
+
+	olds := make([]kyber.Point, size)
+	dealers := make([]uint32, 0)
+	for i, index := range indices {
+		if index >= 0 && olds[index] == nil {
+			olds[index] = keys[i]
+			dealers = append(dealers, uint32(index))
+		}
+	}
+	for i := range olds {
+		if olds[i] == nil {
+			olds[i] = cothority.Suite.Point().Pick(cothority.Suite.RandomStream())
+		}
+	}
+
+	publics := make([]kyber.Point, len(o.NewRoster.List))
+	receivers := make([]uint32, len(o.NewRoster.List))
+	for i, si := range o.NewRoster.List {
+		index, _ := o.Roster().Search(si.ID)
+		if index < 0 {
+			return ERR_UNKNOWN_RECEIVER
+		}
+		publics[i], receivers[i] = keys[index], uint32(index)
+	}
+	log.Lvl3(o.Name(), "resharing from", dealers, "to", receivers)
+
+	return o.fullBroadcast(&StartDeal{
+		Publics:      publics,
+		Threshold:    o.Threshold,
+		Receivers:    receivers,
+		Olds:         olds,
+		Dealers:      dealers,
+		OldThreshold: uint32(len(o.Secret.Commits)),
+		Commits:      o.Secret.Commits,
+	})
+}
+
+// reshare creates the key generator dealing the current share of this node,
+// if any, and receiving a new share if it belongs to the new participants.
+func (o *SetupDKG) reshare(commits []kyber.Point) (*dkg.DistKeyGenerator, error) {
+	config := &dkg.Config{
+		Suite:        cothority.Suite,
+		Longterm:     o.keypair.Private,
+		OldNodes:     o.olds,
+		NewNodes:     o.publics,
+		Threshold:    int(o.Threshold),
+		OldThreshold: o.required,
+	}
+	if o.Secret != nil && o.oldIndex >= 0 {
+		config.Share = &dkg.DistKeyShare{
+			Commits: o.Secret.Commits,
+			Share:   &share.PriShare{I: o.Secret.Index, V: o.Secret.V},
+		}
+	} else {
+		config.PublicCoeffs = commits
+	}
+	return dkg.NewDistKeyHandler(config)
+}
+
+// endorse signs the new share of this node with the Endorse hook. Followers
+// send their signature to the root, which collects the signatures of all new
+// share holders until the timeout expires.
+func (o *SetupDKG) endorse() error {
+	if o.index >= 0 {
+		secret, err := o.SharedSecret()
+		if err != nil {
+			return err
+		}
+		signature, err := o.Endorse(secret)
+		if err != nil {
+			return err
+		} else if !o.IsRoot() {
+			return o.SendToParent(&Endorsement{Signature: signature})
+		}
+		o.Endorsements = append(o.Endorsements, &Endorsement{o.Public(), signature})
+	} else if !o.IsRoot() {
+		return nil
+	}
+
+	timeout := time.After(o.Timeout)
+	for len(o.Endorsements) < len(o.receivers) {
+		select {
+		case e := <-o.structEndorsement:
+			public := e.ServerIdentity.Public
+			o.Endorsements = append(o.Endorsements, &Endorsement{public, e.Signature})
+		case <-timeout:
+			log.Lvl2(o.Name(), "timeout with missing endorsements")
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package dkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dedis/kyber/share"
+	"github.com/dedis/onet"
+	"github.com/stretchr/testify/require"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+// Test protocol handing the shares of an offline DKG to the nodes in roster
+// order. All instances are collected in the channel.
+var (
+	reshared = make(chan *SetupDKG, 10)
+	shares   []*SharedSecret
+)
+
+func init() {
+	onet.GlobalProtocolRegister("ReshareDKGTest", newReshared)
+}
+
+func newReshared(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
+	instance, err := NewSetupDKG(n)
+	if err != nil {
+		return nil, err
+	}
+	o := instance.(*SetupDKG)
+	o.Secret = shares[n.Index()]
+	o.Endorse = func(secret *SharedSecret) ([]byte, error) {
+		return []byte{byte(secret.Index)}, nil
+	}
+	reshared <- o
+	return o, nil
+}
+
+func TestReshare(t *testing.T) {
+	dkgs, err := Simulate(4, 3)
+	require.Nil(t, err)
+	shares = make([]*SharedSecret, len(dkgs))
+	for i := range dkgs {
+		shares[i], _ = NewSharedSecret(dkgs[i])
+	}
+
+	local := onet.NewLocalTest(tSuite)
+	defer local.CloseAll()
+	_, roster, tree := local.GenBigTree(4, 4, 4, true)
+
+	pi, err := local.CreateProtocol("ReshareDKGTest", tree)
+	require.Nil(t, err)
+	root := pi.(*SetupDKG)
+	root.Wait = true
+	root.NewRoster = onet.NewRoster(roster.List[:3])
+	root.Threshold = 2
+	require.Nil(t, pi.Start())
+
+	protocols := make([]*SetupDKG, 4)
+	for range protocols {
+		protocol := <-reshared
+		protocols[protocol.Index()] = protocol
+	}
+	for _, protocol := range protocols {
+		select {
+		case <-protocol.Done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Didn't finish in time")
+		}
+	}
+
+	secrets := make([]*share.PriShare, 0)
+	for i, protocol := range protocols[:3] {
+		secret, err := protocol.SharedSecret()
+		require.Nil(t, err)
+		require.Equal(t, i, secret.Index)
+		require.True(t, shares[0].X.Equal(secret.X))
+		require.True(t, crypto.Suite.Point().Mul(secret.V, nil).Equal(secret.PublicShare(i)))
+		secrets = append(secrets, &share.PriShare{I: secret.Index, V: secret.V})
+	}
+	_, err = protocols[3].SharedSecret()
+	require.NotNil(t, err)
+
+	require.Equal(t, 3, len(root.Endorsements))
+	for _, e := range root.Endorsements {
+		require.Equal(t, 1, len(e.Signature))
+		require.True(t, roster.List[e.Signature[0]].Public.Equal(e.Public))
+	}
+
+	old := make([]*share.PriShare, len(shares))
+	for i, secret := range shares {
+		old[i] = &share.PriShare{I: secret.Index, V: secret.V}
+	}
+	x, _ := share.RecoverSecret(crypto.Suite, secrets[:2], 2, 3)
+	y, _ := share.RecoverSecret(crypto.Suite, old, 3, 4)
+	require.True(t, x.Equal(y))
+}
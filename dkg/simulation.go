@@ -4,7 +4,7 @@ import (
 	"errors"
 
 	"github.com/dedis/kyber"
-	dkg "github.com/dedis/kyber/share/dkg/rabin"
+	dkg "github.com/dedis/kyber/share/dkg/pedersen"
 
 	"github.com/qantik/nevv/crypto"
 )
@@ -29,58 +29,40 @@ func Simulate(nbrNodes, threshold int) (dkgs []*dkg.DistKeyGenerator, err error)
 		}
 	}
 	// Exchange of Deals
-	responses := make([][]*dkg.Response, nbrNodes)
-	for i, p := range dkgs {
-		responses[i] = make([]*dkg.Response, nbrNodes)
+	responses := make([]*dkg.Response, 0, nbrNodes*nbrNodes)
+	for _, p := range dkgs {
 		deals, err := p.Deals()
 		if err != nil {
 			return nil, err
 		}
 		for j, d := range deals {
-			responses[i][j], err = dkgs[j].ProcessDeal(d)
+			response, err := dkgs[j].ProcessDeal(d)
 			if err != nil {
 				return nil, err
 			}
+			responses = append(responses, response)
 		}
 	}
 	// ProcessResponses
-	for _, resp := range responses {
-		for j, r := range resp {
-			for k, p := range dkgs {
-				if r != nil && j != k {
-					justification, err := p.ProcessResponse(r)
-					if err != nil {
-						return nil, err
-					}
-					if justification != nil {
-						return nil, errors.New("there should be no justification")
-					}
-				}
+	for _, r := range responses {
+		for k, p := range dkgs {
+			if uint32(k) == r.Response.Index {
+				continue
 			}
-		}
-	}
-
-	// Secret commits
-	for _, p := range dkgs {
-		commit, err := p.SecretCommits()
-		if err != nil {
-			return nil, err
-		}
-		for _, p2 := range dkgs {
-			compl, err := p2.ProcessSecretCommits(commit)
+			justification, err := p.ProcessResponse(r)
 			if err != nil {
 				return nil, err
 			}
-			if compl != nil {
-				return nil, errors.New("there should be no complaint")
+			if justification != nil {
+				return nil, errors.New("there should be no justification")
 			}
 		}
 	}
 
 	// Verify if all is OK
 	for _, p := range dkgs {
-		if !p.Finished() {
-			return nil, errors.New("one of the dkgs is not finished yet")
+		if !p.Certified() {
+			return nil, errors.New("one of the dkgs is not certified yet")
 		}
 	}
 	return
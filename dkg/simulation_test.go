@@ -6,6 +6,8 @@ import (
 	"github.com/dedis/kyber"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/crypto"
 )
 
 func TestSimulate(t *testing.T) {
@@ -27,3 +29,25 @@ func TestSimulate(t *testing.T) {
 		public, private = secret.X, secret.V
 	}
 }
+
+func TestPublicShare(t *testing.T) {
+	dkgs, _ := Simulate(3, 3)
+
+	for i, dkg := range dkgs {
+		secret, _ := NewSharedSecret(dkg)
+		public := crypto.Suite.Point().Mul(secret.V, nil)
+		assert.True(t, public.Equal(secret.PublicShare(i)))
+	}
+}
+
+func TestPublicShares(t *testing.T) {
+	dkgs, _ := Simulate(3, 2)
+	secret, _ := NewSharedSecret(dkgs[0])
+
+	shares := secret.PublicShares(3)
+	assert.Equal(t, 3, len(shares))
+	for i, dkg := range dkgs {
+		s, _ := NewSharedSecret(dkg)
+		assert.True(t, crypto.Suite.Point().Mul(s.V, nil).Equal(shares[i]))
+	}
+}
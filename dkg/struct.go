@@ -11,9 +11,12 @@ import (
 	"errors"
 
 	"github.com/dedis/kyber"
-	dkg "github.com/dedis/kyber/share/dkg/rabin"
+	"github.com/dedis/kyber/share"
+	dkg "github.com/dedis/kyber/share/dkg/pedersen"
 	"github.com/dedis/onet"
 	"github.com/dedis/onet/network"
+
+	"github.com/qantik/nevv/crypto"
 )
 
 // NameDKG can be used from other packages to refer to this protocol.
@@ -23,8 +26,9 @@ func init() {
 	network.RegisterMessages(&SharedSecret{},
 		&Init{}, &InitReply{},
 		&StartDeal{}, &Deal{},
-		&Response{}, &SecretCommit{},
-		&Verification{}, &VerificationReply{})
+		&Response{}, &Justification{},
+		&Verification{}, &VerificationReply{},
+		&Endorsement{})
 }
 
 // SharedSecret represents the needed information to do shared encryption
@@ -42,9 +46,6 @@ func NewSharedSecret(dkg *dkg.DistKeyGenerator) (*SharedSecret, error) {
 	if dkg == nil {
 		return nil, errors.New("no valid dkg given")
 	}
-	if !dkg.Finished() {
-		return nil, errors.New("dkg is not finished yet")
-	}
 	dks, err := dkg.DistKeyShare()
 	if err != nil {
 		return nil, err
@@ -57,6 +58,26 @@ func NewSharedSecret(dkg *dkg.DistKeyGenerator) (*SharedSecret, error) {
 	}, nil
 }
 
+// Poly returns the public polynomial defined by the DKG commitments.
+func (s *SharedSecret) Poly() *share.PubPoly {
+	return share.NewPubPoly(crypto.Suite, nil, s.Commits)
+}
+
+// PublicShare returns the public share of the node with the given index.
+func (s *SharedSecret) PublicShare(index int) kyber.Point {
+	return s.Poly().Eval(index).V
+}
+
+// PublicShares returns the public shares of n nodes by share index.
+func (s *SharedSecret) PublicShares(n int) []kyber.Point {
+	poly := s.Poly()
+	shares := make([]kyber.Point, n)
+	for i := range shares {
+		shares[i] = poly.Eval(i).V
+	}
+	return shares
+}
+
 // Init asks all nodes to set up a private/public key pair. It is sent to
 // all nodes from the root-node. If Wait is true, at the end of the setup
 // an additional message is sent to wait for all nodes to be set up.
@@ -69,9 +90,11 @@ type structInit struct {
 	Init
 }
 
-// InitReply returns the public key of that node.
+// InitReply returns the public key of that node and the index of its
+// current share, or -1 if it holds none.
 type InitReply struct {
 	Public kyber.Point
+	Index  int
 }
 
 type structInitReply struct {
@@ -79,10 +102,20 @@ type structInitReply struct {
 	InitReply
 }
 
-// StartDeal is used by the leader to initiate the Deals.
+// StartDeal is used by the leader to initiate the Deals. Publics are the keys
+// of the participants, which are found at the Receivers positions of the
+// tree. The other fields are only set when resharing: Olds are the keys of
+// the current share holders by share index, of which the Dealers take part,
+// and Commits are the public coefficients of the shared secret.
 type StartDeal struct {
 	Publics   []kyber.Point
 	Threshold uint32
+	Receivers []uint32
+
+	Olds         []kyber.Point
+	Dealers      []uint32
+	OldThreshold uint32
+	Commits      []kyber.Point
 }
 
 type structStartDeal struct {
@@ -110,14 +143,15 @@ type structResponse struct {
 	Response
 }
 
-// SecretCommit is sent to all other nodes.
-type SecretCommit struct {
-	SecretCommit *dkg.SecretCommits
+// Justification answers a complaint against a deal and is sent to all
+// other nodes.
+type Justification struct {
+	Justification *dkg.Justification
 }
 
-type structSecretCommit struct {
+type structJustification struct {
 	*onet.TreeNode
-	SecretCommit
+	Justification
 }
 
 // Verification asks all nodes to verify the completion of the
@@ -157,3 +191,16 @@ type structWaitReply struct {
 	*onet.TreeNode
 	WaitReply
 }
+
+// Endorsement carries the signature of a node over the outcome of a
+// resharing and is sent to the root. Public is set by the root to the server
+// identity key of the sender.
+type Endorsement struct {
+	Public    kyber.Point
+	Signature []byte
+}
+
+type structEndorsement struct {
+	*onet.TreeNode
+	Endorsement
+}
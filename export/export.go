@@ -0,0 +1,690 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/proof/dleq"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/network"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+)
+
+// Version is the schema version of the bundle format.
+const Version = 3
+
+// Names of the files contained in a bundle.
+const (
+	ELECTION = "election.json"
+	BOX      = "box.csv"
+	MIXES    = "mixes.json"
+	PARTIALS = "partials.json"
+	RESULTS  = "results.json"
+	MANIFEST = "manifest.json"
+)
+
+var (
+	ERR_MISSING_FILE = errors.New("Bundle file is missing")
+	ERR_VERSION      = errors.New("Bundle version is not supported")
+	ERR_HASH         = errors.New("Bundle file does not match its manifest hash")
+	ERR_UNLISTED     = errors.New("Bundle file is not listed in the manifest")
+	ERR_MALFORMED    = errors.New("Bundle file is malformed")
+)
+
+// File is a named artefact of a bundle.
+type File struct {
+	Name string // Name of the file.
+	Data []byte // Data is the encoded content.
+}
+
+// Bundle is a self-contained export of an election. Points and scalars are
+// encoded in canonical hex. The manifest lists the SHA-256 hash of each file.
+type Bundle struct {
+	Files []*File
+}
+
+// Artefacts are the decoded contents of a bundle.
+type Artefacts struct {
+	Election *chains.Election
+	Box      *chains.Box
+	Mixes    []*chains.Mix
+	Partials []*chains.Partial
+	Results  []*chains.Result
+}
+
+// File returns the file with the given name or nil if it is not in the bundle.
+func (b *Bundle) File(name string) *File {
+	for _, file := range b.Files {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
+
+// Build fetches all the artefacts of an election from its skipchain and
+// encodes them into a bundle.
+func Build(election *chains.Election) (*Bundle, error) {
+	box, err := election.Box()
+	if err != nil {
+		return nil, err
+	}
+	mixes, err := election.Mixes()
+	if err != nil {
+		return nil, err
+	}
+	partials, err := election.Partials()
+	if err != nil {
+		return nil, err
+	}
+	results, err := election.Results()
+	if err != nil {
+		return nil, err
+	}
+
+	return Encode(&Artefacts{election, box, mixes, partials, results})
+}
+
+// Encode creates a bundle from a set of artefacts.
+func Encode(a *Artefacts) (*Bundle, error) {
+	e, err := encodeElection(a.Election)
+	if err != nil {
+		return nil, err
+	}
+
+	box, err := encodeBox(a.Box)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make([]*mix, len(a.Mixes))
+	for i, x := range a.Mixes {
+		proofs := make([]string, len(x.Proofs))
+		for j, proof := range x.Proofs {
+			proofs[j] = hex.EncodeToString(proof)
+		}
+		m[i] = &mix{
+			Node:      x.Node,
+			Proofs:    proofs,
+			Ballots:   encodeBallots(x.Ballots),
+			Signer:    encodePoint(x.Signer),
+			Signature: hex.EncodeToString(x.Signature),
+		}
+	}
+	mixes, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	p := make([]*partial, len(a.Partials))
+	for i, x := range a.Partials {
+		p[i] = encodePartial(x)
+	}
+	partials, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := json.MarshalIndent(a.Results, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{Files: []*File{
+		&File{ELECTION, e}, &File{BOX, box}, &File{MIXES, mixes},
+		&File{PARTIALS, partials}, &File{RESULTS, results},
+	}}
+
+	manifest := &manifest{Version: Version, Election: hex.EncodeToString(a.Election.ID)}
+	for _, file := range bundle.Files {
+		manifest.Files = append(manifest.Files, &entry{file.Name, digest(file.Data)})
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	bundle.Files = append(bundle.Files, &File{MANIFEST, data})
+	return bundle, nil
+}
+
+// Decode checks the version and the hashes of a bundle against its manifest,
+// which must list every file, before decoding the artefacts.
+func Decode(bundle *Bundle) (*Artefacts, error) {
+	file := bundle.File(MANIFEST)
+	if file == nil {
+		return nil, ERR_MISSING_FILE
+	}
+
+	m := &manifest{}
+	if err := json.Unmarshal(file.Data, m); err != nil {
+		return nil, err
+	} else if m.Version != Version {
+		return nil, ERR_VERSION
+	}
+
+	listed := make(map[string]bool)
+	for _, entry := range m.Files {
+		file := bundle.File(entry.Name)
+		if file == nil {
+			return nil, ERR_MISSING_FILE
+		} else if digest(file.Data) != entry.Hash {
+			return nil, ERR_HASH
+		}
+		listed[entry.Name] = true
+	}
+
+	for _, name := range []string{ELECTION, BOX, MIXES, PARTIALS, RESULTS} {
+		if bundle.File(name) == nil {
+			return nil, ERR_MISSING_FILE
+		}
+	}
+	for _, file := range bundle.Files {
+		if file.Name != MANIFEST && !listed[file.Name] {
+			return nil, ERR_UNLISTED
+		}
+	}
+
+	a := &Artefacts{}
+	var err error
+	if a.Election, err = decodeElection(bundle.File(ELECTION).Data); err != nil {
+		return nil, err
+	}
+	if a.Box, err = decodeBox(bundle.File(BOX).Data); err != nil {
+		return nil, err
+	}
+
+	mixes := make([]*mix, 0)
+	if err = json.Unmarshal(bundle.File(MIXES).Data, &mixes); err != nil {
+		return nil, err
+	}
+	for _, m := range mixes {
+		proofs := make([][]byte, len(m.Proofs))
+		for i, proof := range m.Proofs {
+			if proofs[i], err = hex.DecodeString(proof); err != nil {
+				return nil, err
+			}
+		}
+		ballots, err := decodeBallots(m.Ballots)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := decodePoint(m.Signer)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := hex.DecodeString(m.Signature)
+		if err != nil {
+			return nil, err
+		}
+		a.Mixes = append(a.Mixes, &chains.Mix{
+			Ballots:   ballots,
+			Proofs:    proofs,
+			Node:      m.Node,
+			Signer:    signer,
+			Signature: signature,
+		})
+	}
+
+	partials := make([]*partial, 0)
+	if err = json.Unmarshal(bundle.File(PARTIALS).Data, &partials); err != nil {
+		return nil, err
+	}
+	for _, p := range partials {
+		partial, err := decodePartial(p)
+		if err != nil {
+			return nil, err
+		}
+		a.Partials = append(a.Partials, partial)
+	}
+
+	if err = json.Unmarshal(bundle.File(RESULTS).Data, &a.Results); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// manifest lists the files of a bundle with their hashes.
+type manifest struct {
+	Version  uint32   `json:"version"`
+	Election string   `json:"election"`
+	Files    []*entry `json:"files"`
+}
+
+// entry is the hash of a single file in the manifest.
+type entry struct {
+	Name string `json:"name"`
+	Hash string `json:"sha256"`
+}
+
+// server is the exported form of a roster conode.
+type server struct {
+	Address     string `json:"address"`
+	Public      string `json:"public"`
+	Description string `json:"description,omitempty"`
+}
+
+// election is the exported form of an election.
+type election struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Creator     uint32         `json:"creator"`
+	Users       []uint32       `json:"users"`
+	Weights     []uint32       `json:"weights,omitempty"`
+	Roster      []*server      `json:"roster"`
+	Key         string         `json:"key"`
+	Stage       uint32         `json:"stage"`
+	Policy      uint32         `json:"policy"`
+	Proofs      bool           `json:"proofs"`
+	Schema      *chains.Schema `json:"schema,omitempty"`
+	Quorum      uint32         `json:"quorum,omitempty"`
+	MinMixes    uint32         `json:"min_mixes,omitempty"`
+	Mixnet      string         `json:"mixnet,omitempty"`
+	Threshold   uint32         `json:"threshold,omitempty"`
+	Commits     []string       `json:"commits,omitempty"`
+	Shares      []string       `json:"shares,omitempty"`
+	Contests    []*contest     `json:"contests,omitempty"`
+	Description string         `json:"description,omitempty"`
+	End         string         `json:"end,omitempty"`
+}
+
+// contest is the exported form of a contest.
+type contest struct {
+	Name   string         `json:"name"`
+	Users  []uint32       `json:"users,omitempty"`
+	Schema *chains.Schema `json:"schema,omitempty"`
+}
+
+// ballot is the exported form of a weighted ciphertext.
+type ballot struct {
+	Alpha   string `json:"alpha"`
+	Beta    string `json:"beta"`
+	Weight  uint32 `json:"weight"`
+	Contest uint32 `json:"contest"`
+}
+
+// mix is the exported form of a mix.
+type mix struct {
+	Node      string    `json:"node"`
+	Proofs    []string  `json:"proofs"`
+	Ballots   []*ballot `json:"ballots"`
+	Signer    string    `json:"signer"`
+	Signature string    `json:"signature"`
+}
+
+// proof is the exported form of a decryption proof.
+type proof struct {
+	C  string `json:"c"`
+	R  string `json:"r"`
+	VG string `json:"vg"`
+	VH string `json:"vh"`
+}
+
+// partial is the exported form of a partial decryption.
+type partial struct {
+	Node   string   `json:"node"`
+	Index  int      `json:"index"`
+	Flag   bool     `json:"flag"`
+	Public string   `json:"public"`
+	Points []string `json:"points"`
+	Proofs []*proof `json:"proofs"`
+
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
+
+func encodeElection(e *chains.Election) ([]byte, error) {
+	roster := make([]*server, 0)
+	if e.Roster != nil {
+		for _, si := range e.Roster.List {
+			roster = append(roster, &server{string(si.Address), encodePoint(si.Public), si.Description})
+		}
+	}
+
+	contests := make([]*contest, len(e.Contests))
+	for i, c := range e.Contests {
+		contests[i] = &contest{c.Name, c.Users, c.Schema}
+	}
+
+	return json.MarshalIndent(&election{
+		ID:          hex.EncodeToString(e.ID),
+		Name:        e.Name,
+		Creator:     e.Creator,
+		Users:       e.Users,
+		Weights:     e.Weights,
+		Roster:      roster,
+		Key:         encodePoint(e.Key),
+		Stage:       e.Stage,
+		Policy:      e.Policy,
+		Proofs:      e.Proofs,
+		Schema:      e.Schema,
+		Quorum:      e.Quorum,
+		MinMixes:    e.MinMixes,
+		Mixnet:      e.Mixnet,
+		Threshold:   e.Threshold,
+		Commits:     encodePoints(e.Commits),
+		Shares:      encodePoints(e.Shares),
+		Contests:    contests,
+		Description: e.Description,
+		End:         e.End,
+	}, "", "  ")
+}
+
+func decodeElection(data []byte) (*chains.Election, error) {
+	e := &election{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, err
+	}
+
+	id, err := hex.DecodeString(e.ID)
+	if err != nil {
+		return nil, err
+	}
+	key, err := decodePoint(e.Key)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := decodePoints(e.Commits)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := decodePoints(e.Shares)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*network.ServerIdentity, len(e.Roster))
+	for i, s := range e.Roster {
+		public, err := decodePoint(s.Public)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = network.NewServerIdentity(public, network.Address(s.Address))
+		list[i].Description = s.Description
+	}
+
+	contests := make([]*chains.Contest, len(e.Contests))
+	for i, c := range e.Contests {
+		contests[i] = &chains.Contest{Name: c.Name, Users: c.Users, Schema: c.Schema}
+	}
+
+	return &chains.Election{
+		Name:        e.Name,
+		Creator:     e.Creator,
+		Users:       e.Users,
+		Weights:     e.Weights,
+		ID:          id,
+		Roster:      onet.NewRoster(list),
+		Key:         key,
+		Stage:       e.Stage,
+		Policy:      e.Policy,
+		Proofs:      e.Proofs,
+		Schema:      e.Schema,
+		Quorum:      e.Quorum,
+		MinMixes:    e.MinMixes,
+		Mixnet:      e.Mixnet,
+		Threshold:   e.Threshold,
+		Commits:     commits,
+		Shares:      shares,
+		Contests:    contests,
+		Description: e.Description,
+		End:         e.End,
+	}, nil
+}
+
+func encodeBox(box *chains.Box) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	writer.Write([]string{"user", "alpha", "beta", "proof", "weight", "contest"})
+	for _, b := range box.Ballots {
+		user := strconv.FormatUint(uint64(b.User), 10)
+		proof := hex.EncodeToString(b.Proof)
+		weight := strconv.FormatUint(uint64(b.Weight), 10)
+		contest := strconv.FormatUint(uint64(b.Contest), 10)
+		writer.Write([]string{user, encodePoint(b.Alpha), encodePoint(b.Beta), proof, weight, contest})
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func decodeBox(data []byte) (*chains.Box, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, ERR_MALFORMED
+	}
+
+	box := &chains.Box{Ballots: make([]*chains.Ballot, 0)}
+	for _, record := range records[1:] {
+		if len(record) != 6 {
+			return nil, ERR_MALFORMED
+		}
+		user, err := strconv.ParseUint(record[0], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		alpha, err := decodePoint(record[1])
+		if err != nil {
+			return nil, err
+		}
+		beta, err := decodePoint(record[2])
+		if err != nil {
+			return nil, err
+		}
+		proof, err := hex.DecodeString(record[3])
+		if err != nil {
+			return nil, err
+		}
+		weight, err := strconv.ParseUint(record[4], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		contest, err := strconv.ParseUint(record[5], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		box.Ballots = append(box.Ballots, &chains.Ballot{
+			User:    uint32(user),
+			Alpha:   alpha,
+			Beta:    beta,
+			Proof:   proof,
+			Weight:  uint32(weight),
+			Contest: uint32(contest),
+		})
+	}
+	return box, nil
+}
+
+func encodeBallots(ballots []*chains.Ballot) []*ballot {
+	encoded := make([]*ballot, len(ballots))
+	for i, b := range ballots {
+		encoded[i] = &ballot{encodePoint(b.Alpha), encodePoint(b.Beta), b.Weight, b.Contest}
+	}
+	return encoded
+}
+
+func decodeBallots(ballots []*ballot) ([]*chains.Ballot, error) {
+	decoded := make([]*chains.Ballot, len(ballots))
+	for i, b := range ballots {
+		alpha, err := decodePoint(b.Alpha)
+		if err != nil {
+			return nil, err
+		}
+		beta, err := decodePoint(b.Beta)
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = &chains.Ballot{Alpha: alpha, Beta: beta, Weight: b.Weight, Contest: b.Contest}
+	}
+	return decoded, nil
+}
+
+func encodePartial(p *chains.Partial) *partial {
+	points := make([]string, len(p.Points))
+	for i, point := range p.Points {
+		points[i] = encodePoint(point)
+	}
+
+	proofs := make([]*proof, len(p.Proofs))
+	for i, x := range p.Proofs {
+		if x != nil {
+			proofs[i] = &proof{encodeScalar(x.C), encodeScalar(x.R), encodePoint(x.VG), encodePoint(x.VH)}
+		}
+	}
+
+	return &partial{
+		Node:   p.Node,
+		Index:  p.Index,
+		Flag:   p.Flag,
+		Public: encodePoint(p.Public),
+		Points: points,
+		Proofs: proofs,
+
+		Signer:    encodePoint(p.Signer),
+		Signature: hex.EncodeToString(p.Signature),
+	}
+}
+
+func decodePartial(p *partial) (*chains.Partial, error) {
+	public, err := decodePoint(p.Public)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := decodePoint(p.Signer)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := hex.DecodeString(p.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]kyber.Point, len(p.Points))
+	for i, point := range p.Points {
+		if points[i], err = decodePoint(point); err != nil {
+			return nil, err
+		}
+	}
+
+	proofs := make([]*dleq.Proof, len(p.Proofs))
+	for i, x := range p.Proofs {
+		if x == nil {
+			continue
+		}
+		proofs[i] = &dleq.Proof{}
+		if proofs[i].C, err = decodeScalar(x.C); err != nil {
+			return nil, err
+		}
+		if proofs[i].R, err = decodeScalar(x.R); err != nil {
+			return nil, err
+		}
+		if proofs[i].VG, err = decodePoint(x.VG); err != nil {
+			return nil, err
+		}
+		if proofs[i].VH, err = decodePoint(x.VH); err != nil {
+			return nil, err
+		}
+	}
+
+	return &chains.Partial{
+		Points: points,
+		Proofs: proofs,
+		Index:  p.Index,
+		Public: public,
+		Flag:   p.Flag,
+		Node:   p.Node,
+
+		Signer:    signer,
+		Signature: signature,
+	}, nil
+}
+
+// encodePoint returns the canonical hex encoding of a point.
+func encodePoint(point kyber.Point) string {
+	if point == nil {
+		return ""
+	}
+	buf, _ := point.MarshalBinary()
+	return hex.EncodeToString(buf)
+}
+
+// decodePoint parses a hex encoded point.
+func decodePoint(s string) (kyber.Point, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	point := crypto.Suite.Point()
+	if err = point.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return point, nil
+}
+
+// encodePoints encodes a list of points.
+func encodePoints(points []kyber.Point) []string {
+	encoded := make([]string, len(points))
+	for i, point := range points {
+		encoded[i] = encodePoint(point)
+	}
+	return encoded
+}
+
+// decodePoints parses a list of hex encoded points.
+func decodePoints(encoded []string) ([]kyber.Point, error) {
+	points := make([]kyber.Point, len(encoded))
+	for i, s := range encoded {
+		point, err := decodePoint(s)
+		if err != nil {
+			return nil, err
+		}
+		points[i] = point
+	}
+	return points, nil
+}
+
+// encodeScalar returns the canonical hex encoding of a scalar.
+func encodeScalar(scalar kyber.Scalar) string {
+	if scalar == nil {
+		return ""
+	}
+	buf, _ := scalar.MarshalBinary()
+	return hex.EncodeToString(buf)
+}
+
+// decodeScalar parses a hex encoded scalar.
+func decodeScalar(s string) (kyber.Scalar, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	scalar := crypto.Suite.Scalar()
+	if err = scalar.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return scalar, nil
+}
+
+// digest returns the hex encoded SHA-256 hash of some data.
+func digest(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,167 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/proof/dleq"
+	"github.com/dedis/onet"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestEncode(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.DECRYPTED}
+	_ = election.GenChain(5, testutil.Keys(local, nodes)...)
+
+	bundle, _ := Build(election)
+	a, err := Decode(bundle)
+	assert.Nil(t, err)
+
+	assert.Equal(t, election.ID, a.Election.ID)
+	assert.Equal(t, election.Roster.ID, a.Election.Roster.ID)
+	assert.True(t, election.Key.Equal(a.Election.Key))
+	assert.Nil(t, a.Election.VerifyTranscript())
+
+	box, _ := election.Box()
+	assert.Equal(t, len(box.Ballots), len(a.Box.Ballots))
+	for i := range box.Ballots {
+		assert.Equal(t, box.Ballots[i].User, a.Box.Ballots[i].User)
+		assert.True(t, box.Ballots[i].Alpha.Equal(a.Box.Ballots[i].Alpha))
+		assert.True(t, box.Ballots[i].Beta.Equal(a.Box.Ballots[i].Beta))
+	}
+
+	mixes, _ := election.Mixes()
+	assert.Equal(t, len(mixes), len(a.Mixes))
+	for i := range mixes {
+		assert.Equal(t, mixes[i].Proofs, a.Mixes[i].Proofs)
+		assert.True(t, mixes[i].Ballots[0].Alpha.Equal(a.Mixes[i].Ballots[0].Alpha))
+		assert.True(t, mixes[i].Signer.Equal(a.Mixes[i].Signer))
+		assert.Equal(t, mixes[i].Signature, a.Mixes[i].Signature)
+		assert.Nil(t, a.Mixes[i].Verify(election.ID))
+	}
+
+	partials, _ := election.Partials()
+	assert.Equal(t, len(partials), len(a.Partials))
+	for i := range partials {
+		assert.Equal(t, partials[i].Index, a.Partials[i].Index)
+		assert.True(t, partials[i].Public.Equal(a.Partials[i].Public))
+		assert.True(t, partials[i].Points[0].Equal(a.Partials[i].Points[0]))
+		assert.True(t, partials[i].Proofs[0].C.Equal(a.Partials[i].Proofs[0].C))
+		assert.True(t, partials[i].Signer.Equal(a.Partials[i].Signer))
+		assert.Equal(t, partials[i].Signature, a.Partials[i].Signature)
+		assert.Nil(t, a.Partials[i].Verify(election.ID))
+	}
+	assert.Equal(t, 0, len(a.Results))
+}
+
+func TestDecode_Tampered(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.SHUFFLED}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	bundle, _ := Build(election)
+	bundle.File(BOX).Data[0] ^= 1
+	_, err := Decode(bundle)
+	assert.Equal(t, ERR_HASH, err)
+}
+
+func TestDecode_Version(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.RUNNING}
+	_ = election.GenChain(3)
+
+	bundle, _ := Build(election)
+	bundle.File(MANIFEST).Data = []byte(`{"version": 0}`)
+	_, err := Decode(bundle)
+	assert.Equal(t, ERR_VERSION, err)
+}
+
+func TestDecode_MissingFile(t *testing.T) {
+	_, err := Decode(&Bundle{})
+	assert.Equal(t, ERR_MISSING_FILE, err)
+}
+
+func TestDecode_Unlisted(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.RUNNING}
+	_ = election.GenChain(3)
+
+	a, _ := Build(election)
+	bundle := &Bundle{Files: []*File{a.File(ELECTION), a.File(MIXES), a.File(PARTIALS),
+		a.File(RESULTS)}}
+	bundle.Files = append(bundle.Files, rehash(bundle), a.File(BOX))
+	_, err := Decode(bundle)
+	assert.Equal(t, ERR_UNLISTED, err)
+}
+
+func TestDecode_Malformed(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.RUNNING}
+	_ = election.GenChain(3)
+
+	for _, data := range []string{"", "user\n1\n"} {
+		a, _ := Build(election)
+		a.File(BOX).Data = []byte(data)
+
+		files := make([]*File, 0)
+		for _, file := range a.Files {
+			if file.Name != MANIFEST {
+				files = append(files, file)
+			}
+		}
+		bundle := &Bundle{Files: files}
+		bundle.Files = append(bundle.Files, rehash(bundle))
+
+		_, err := Decode(bundle)
+		assert.Equal(t, ERR_MALFORMED, err)
+	}
+}
+
+func TestEncodePartial_MissingProof(t *testing.T) {
+	partial := &chains.Partial{
+		Points: []kyber.Point{crypto.Suite.Point().Base()},
+		Proofs: []*dleq.Proof{nil},
+	}
+
+	decoded, err := decodePartial(encodePartial(partial))
+	assert.Nil(t, err)
+	assert.Nil(t, decoded.Proofs[0])
+	assert.Equal(t, chains.ERR_INCOMPLETE, decoded.Complete())
+}
+
+// rehash creates the manifest of the files of a bundle.
+func rehash(bundle *Bundle) *File {
+	m := &manifest{Version: Version}
+	for _, file := range bundle.Files {
+		m.Files = append(m.Files, &entry{file.Name, digest(file.Data)})
+	}
+	data, _ := json.Marshal(m)
+	return &File{MANIFEST, data}
+}
@@ -0,0 +1,683 @@
+// Package heliosjson maps nevv elections onto the JSON layout of Helios [1].
+// Helios questionnaires are imported as ballot schemas, and elections, voters,
+// ballots, mixes, trustee data and results are written in the same shapes.
+//
+// The package is not compatible with Helios tooling. Helios works in a prime
+// order subgroup of Z_p* whereas nevv uses Ed25519, so group elements and
+// scalars are canonical hex encodings and the group parameters p, q and g are
+// absent. Elections are audited with the nevv verifier instead.
+//
+// Every contest of an election is a Helios question. Each nevv ballot is a
+// single ciphertext, hence a vote has exactly one choice in the answer of its
+// contest and no choices in the answers before.
+//
+// [1] https://github.com/benadida/helios-server
+package heliosjson
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/dedis/kyber"
+	"github.com/dedis/kyber/proof/dleq"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+)
+
+var (
+	ERR_UNKNOWN_TALLY = errors.New("Unknown Helios tally type")
+	ERR_UNKNOWN_VOTER = errors.New("Vote was cast by an unknown voter")
+	ERR_INVALID_VOTE  = errors.New("Vote must have exactly one choice in its last answer")
+	ERR_NO_QUESTION   = errors.New("Election must have at least one question")
+	ERR_INVALID_SHAPE = errors.New("Trustee data does not match the mix")
+)
+
+// Helios tally types.
+const (
+	HOMOMORPHIC = "homomorphic"
+	IRV         = "irv"
+	STV         = "stv"
+)
+
+// PublicKey is an ElGamal public key.
+type PublicKey struct {
+	G string `json:"g"` // G is the generator.
+	Y string `json:"y"` // Y is the public key.
+}
+
+// Question is a single question of a Helios questionnaire.
+type Question struct {
+	Question   string    `json:"question"`
+	ShortName  string    `json:"short_name"`
+	Answers    []string  `json:"answers"`
+	AnswerURLs []*string `json:"answer_urls"`
+	ChoiceType string    `json:"choice_type"`
+	TallyType  string    `json:"tally_type"`
+	ResultType string    `json:"result_type"`
+	Min        int       `json:"min"`
+	Max        *int      `json:"max"`
+}
+
+// Election is a Helios election.
+type Election struct {
+	UUID            string      `json:"uuid"`
+	Name            string      `json:"name"`
+	ShortName       string      `json:"short_name"`
+	Description     string      `json:"description"`
+	PublicKey       *PublicKey  `json:"public_key"`
+	Questions       []*Question `json:"questions"`
+	Openreg         bool        `json:"openreg"`
+	UseVoterAliases bool        `json:"use_voter_aliases"`
+	VotingEndsAt    *string     `json:"voting_ends_at"`
+}
+
+// Voter is an entry of the Helios voter list.
+type Voter struct {
+	ElectionUUID string `json:"election_uuid"`
+	UUID         string `json:"uuid"`
+	VoterType    string `json:"voter_type"`
+	VoterID      string `json:"voter_id"`
+	Name         string `json:"name"`
+}
+
+// Ciphertext is an ElGamal ciphertext.
+type Ciphertext struct {
+	Alpha string `json:"alpha"`
+	Beta  string `json:"beta"`
+}
+
+// Commitment is the commitment of a zero-knowledge proof.
+type Commitment struct {
+	A string `json:"A"`
+	B string `json:"B,omitempty"`
+}
+
+// Proof is a Chaum-Pedersen or Schnorr proof.
+type Proof struct {
+	Challenge  string      `json:"challenge"`
+	Commitment *Commitment `json:"commitment"`
+	Response   string      `json:"response"`
+}
+
+// Answer is the encrypted answer to a question.
+type Answer struct {
+	Choices          []*Ciphertext `json:"choices"`
+	IndividualProofs [][]*Proof    `json:"individual_proofs"`
+	OverallProof     []*Proof      `json:"overall_proof"`
+}
+
+// Vote is an encrypted Helios ballot.
+type Vote struct {
+	Answers      []*Answer `json:"answers"`
+	ElectionHash string    `json:"election_hash"`
+	ElectionUUID string    `json:"election_uuid"`
+}
+
+// CastVote is a vote on the Helios bulletin board.
+type CastVote struct {
+	Vote      *Vote  `json:"vote"`
+	VoteHash  string `json:"vote_hash"`
+	VoterUUID string `json:"voter_uuid"`
+	VoterHash string `json:"voter_hash"`
+}
+
+// Mix is a shuffled list of ciphertexts in the format of the Helios mixnet
+// extension. It carries one proof per class of contest and weight, and lists
+// the contest and the weight of each ciphertext.
+type Mix struct {
+	Mixer         string        `json:"mixer"`
+	MixedAnswers  []*Ciphertext `json:"mixed_answers"`
+	ShuffleProofs []string      `json:"shuffle_proofs"`
+	Contests      []uint32      `json:"contests,omitempty"`
+	Weights       []uint32      `json:"weights,omitempty"`
+}
+
+// Trustee holds the decryption factors of a single node.
+type Trustee struct {
+	UUID              string     `json:"uuid"`
+	Email             string     `json:"email"`
+	PublicKey         *PublicKey `json:"public_key"`
+	PublicKeyHash     string     `json:"public_key_hash"`
+	DecryptionFactors [][]string `json:"decryption_factors"`
+	DecryptionProofs  [][]*Proof `json:"decryption_proofs"`
+}
+
+// Result is the Helios result, the vote count of each answer per question.
+type Result [][]int
+
+// NewElection converts a nevv election into a Helios election.
+func NewElection(e *chains.Election) *Election {
+	election := &Election{
+		UUID:        uuid(e.ID),
+		Name:        e.Name,
+		ShortName:   e.ID.Short(),
+		Description: e.Description,
+		PublicKey:   &PublicKey{G: point(crypto.Base), Y: point(e.Key)},
+		Questions:   make([]*Question, 0),
+	}
+	for _, contest := range e.Races() {
+		election.Questions = append(election.Questions, newQuestion(e, contest))
+	}
+	if e.End != "" {
+		end := e.End
+		election.VotingEndsAt = &end
+	}
+	return election
+}
+
+// Election converts a Helios election into a nevv election. Questionnaires
+// with several questions become contests. The roster, the voters and the
+// skipchain ID are not part of the Helios format.
+func (e *Election) Election() (*chains.Election, error) {
+	if len(e.Questions) == 0 {
+		return nil, ERR_NO_QUESTION
+	}
+
+	contests := make([]*chains.Contest, len(e.Questions))
+	for i, question := range e.Questions {
+		schema, err := question.Schema()
+		if err != nil {
+			return nil, err
+		}
+		contests[i] = &chains.Contest{Name: question.Question, Schema: schema}
+	}
+
+	var err error
+	election := &chains.Election{Name: e.Name, Description: e.Description}
+	if len(contests) == 1 {
+		election.Schema = contests[0].Schema
+	} else {
+		election.Contests = contests
+	}
+	if e.PublicKey != nil && e.PublicKey.Y != "" {
+		if election.Key, err = parsePoint(e.PublicKey.Y); err != nil {
+			return nil, err
+		}
+	}
+	if e.VotingEndsAt != nil {
+		election.End = *e.VotingEndsAt
+	}
+	return election, nil
+}
+
+// Hash returns the Helios election fingerprint.
+func (e *Election) Hash() (string, error) {
+	return hash(e)
+}
+
+// newQuestion derives a question from the schema of a contest. The number of
+// seats is exported as the maximum number of choices.
+func newQuestion(e *chains.Election, contest *chains.Contest) *Question {
+	question := &Question{
+		Question:   contest.Name,
+		ShortName:  e.ID.Short(),
+		Answers:    []string{},
+		AnswerURLs: []*string{},
+		ChoiceType: "approval",
+		TallyType:  HOMOMORPHIC,
+		ResultType: "absolute",
+	}
+	schema := contest.Schema
+	if schema == nil {
+		return question
+	}
+
+	max := int(schema.Seats)
+	if max == 0 {
+		max = 1
+	}
+	question.Max = &max
+	question.Answers = schema.Candidates
+	question.AnswerURLs = make([]*string, len(schema.Candidates))
+
+	switch schema.Method {
+	case chains.IRV:
+		question.TallyType = IRV
+	case chains.STV:
+		question.TallyType = STV
+	}
+	return question
+}
+
+// Schema converts a Helios question into a nevv ballot schema. Homomorphic
+// questions with a single choice are counted by plurality, the others by
+// approval voting.
+func (q *Question) Schema() (*chains.Schema, error) {
+	seats := len(q.Answers)
+	if q.Max != nil {
+		seats = *q.Max
+	}
+
+	schema := &chains.Schema{Candidates: q.Answers, Seats: uint32(seats)}
+	switch q.TallyType {
+	case HOMOMORPHIC, "":
+		if seats == 1 {
+			schema.Method = chains.PLURALITY
+		} else {
+			schema.Method = chains.APPROVAL
+		}
+	case IRV:
+		schema.Method, schema.Seats = chains.IRV, 1
+	case STV:
+		schema.Method = chains.STV
+	default:
+		return nil, ERR_UNKNOWN_TALLY
+	}
+	return schema, nil
+}
+
+// NewVoters creates the Helios voter list of an election.
+func NewVoters(e *chains.Election) []*Voter {
+	voters := make([]*Voter, len(e.Users))
+	for i, user := range e.Users {
+		id := strconv.FormatUint(uint64(user), 10)
+		voters[i] = &Voter{
+			ElectionUUID: uuid(e.ID),
+			UUID:         voterUUID(e, user),
+			VoterType:    "sciper",
+			VoterID:      id,
+			Name:         id,
+		}
+	}
+	return voters
+}
+
+// NewCastVotes converts the ballots of an election into Helios cast votes.
+func NewCastVotes(e *chains.Election, ballots []*chains.Ballot) ([]*CastVote, error) {
+	election, err := NewElection(e).Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	voters := make(map[uint32]*Voter)
+	for _, voter := range NewVoters(e) {
+		user, _ := strconv.ParseUint(voter.VoterID, 10, 32)
+		voters[uint32(user)] = voter
+	}
+
+	votes := make([]*CastVote, len(ballots))
+	for i, ballot := range ballots {
+		answers := make([]*Answer, ballot.Contest+1)
+		for j := range answers {
+			answers[j] = &Answer{[]*Ciphertext{}, [][]*Proof{}, []*Proof{}}
+		}
+		answers[ballot.Contest] = &Answer{
+			Choices:          []*Ciphertext{&Ciphertext{point(ballot.Alpha), point(ballot.Beta)}},
+			IndividualProofs: [][]*Proof{},
+			OverallProof:     splitSchnorr(ballot.Proof),
+		}
+		vote := &Vote{Answers: answers, ElectionHash: election, ElectionUUID: uuid(e.ID)}
+
+		voter, found := voters[ballot.User]
+		if !found {
+			voter = &Voter{UUID: voterUUID(e, ballot.User)}
+		}
+
+		voteHash, err := hash(vote)
+		if err != nil {
+			return nil, err
+		}
+		voterHash, err := hash(voter)
+		if err != nil {
+			return nil, err
+		}
+		votes[i] = &CastVote{vote, voteHash, voter.UUID, voterHash}
+	}
+	return votes, nil
+}
+
+// Ballot converts a Helios cast vote into a nevv ballot. The voter is looked
+// up by its UUID in the given voter list.
+func (c *CastVote) Ballot(voters []*Voter) (*chains.Ballot, error) {
+	if c.Vote == nil || len(c.Vote.Answers) == 0 {
+		return nil, ERR_INVALID_VOTE
+	}
+
+	contest := len(c.Vote.Answers) - 1
+	for i, answer := range c.Vote.Answers {
+		if (i == contest && len(answer.Choices) != 1) || (i < contest && len(answer.Choices) != 0) {
+			return nil, ERR_INVALID_VOTE
+		}
+	}
+
+	var user uint32
+	found := false
+	for _, voter := range voters {
+		if voter.UUID == c.VoterUUID {
+			id, err := strconv.ParseUint(voter.VoterID, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			user, found = uint32(id), true
+			break
+		}
+	}
+	if !found {
+		return nil, ERR_UNKNOWN_VOTER
+	}
+
+	answer := c.Vote.Answers[contest]
+	alpha, err := parsePoint(answer.Choices[0].Alpha)
+	if err != nil {
+		return nil, err
+	}
+	beta, err := parsePoint(answer.Choices[0].Beta)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := joinSchnorr(answer.OverallProof)
+	if err != nil {
+		return nil, err
+	}
+	return &chains.Ballot{
+		User:    user,
+		Alpha:   alpha,
+		Beta:    beta,
+		Proof:   proof,
+		Contest: uint32(contest),
+	}, nil
+}
+
+// NewMix converts a nevv mix into the Helios mixnet format.
+func NewMix(mix *chains.Mix) *Mix {
+	answers := make([]*Ciphertext, len(mix.Ballots))
+	contests := make([]uint32, len(mix.Ballots))
+	for i, ballot := range mix.Ballots {
+		answers[i] = &Ciphertext{point(ballot.Alpha), point(ballot.Beta)}
+		contests[i] = ballot.Contest
+	}
+
+	proofs := make([]string, len(mix.Proofs))
+	for i, proof := range mix.Proofs {
+		proofs[i] = hex.EncodeToString(proof)
+	}
+	return &Mix{
+		Mixer:         mix.Node,
+		MixedAnswers:  answers,
+		ShuffleProofs: proofs,
+		Contests:      contests,
+		Weights:       mix.Weights(),
+	}
+}
+
+// Mix converts a Helios mix into a nevv mix.
+func (m *Mix) Mix() (*chains.Mix, error) {
+	ballots := make([]*chains.Ballot, len(m.MixedAnswers))
+	for i, answer := range m.MixedAnswers {
+		alpha, err := parsePoint(answer.Alpha)
+		if err != nil {
+			return nil, err
+		}
+		beta, err := parsePoint(answer.Beta)
+		if err != nil {
+			return nil, err
+		}
+		ballots[i] = &chains.Ballot{Alpha: alpha, Beta: beta}
+		if i < len(m.Contests) {
+			ballots[i].Contest = m.Contests[i]
+		}
+		if i < len(m.Weights) {
+			ballots[i].Weight = m.Weights[i]
+		}
+	}
+
+	proofs := make([][]byte, len(m.ShuffleProofs))
+	for i, proof := range m.ShuffleProofs {
+		var err error
+		if proofs[i], err = hex.DecodeString(proof); err != nil {
+			return nil, err
+		}
+	}
+	return &chains.Mix{Ballots: ballots, Proofs: proofs, Node: m.Mixer}, nil
+}
+
+// NewTrustee converts the partial decryption of the given mix into Helios
+// trustee data. The decryption factor of a ciphertext (K, C) with partial
+// decryption M is C - M, the Helios alpha^x.
+func NewTrustee(e *chains.Election, mix *chains.Mix, partial *chains.Partial) (*Trustee, error) {
+	if len(partial.Points) != len(mix.Ballots) || len(partial.Proofs) != len(mix.Ballots) {
+		return nil, ERR_INVALID_SHAPE
+	}
+
+	public := &PublicKey{G: point(crypto.Base), Y: point(partial.Public)}
+	publicHash, err := hash(public)
+	if err != nil {
+		return nil, err
+	}
+
+	factors, proofs := make([]string, len(mix.Ballots)), make([]*Proof, len(mix.Ballots))
+	for i, ballot := range mix.Ballots {
+		factors[i] = point(crypto.Suite.Point().Sub(ballot.Beta, partial.Points[i]))
+		proofs[i] = &Proof{
+			Challenge:  scalar(partial.Proofs[i].C),
+			Commitment: &Commitment{A: point(partial.Proofs[i].VG), B: point(partial.Proofs[i].VH)},
+			Response:   scalar(partial.Proofs[i].R),
+		}
+	}
+
+	return &Trustee{
+		UUID:              uuid(e.ID, []byte(partial.Node)),
+		Email:             partial.Node,
+		PublicKey:         public,
+		PublicKeyHash:     publicHash,
+		DecryptionFactors: [][]string{factors},
+		DecryptionProofs:  [][]*Proof{proofs},
+	}, nil
+}
+
+// Partial converts Helios trustee data into the partial decryption of the
+// given mix. Helios trustees are not indexed, so the share index is passed in.
+func (t *Trustee) Partial(mix *chains.Mix, index int) (*chains.Partial, error) {
+	if len(t.DecryptionFactors) != 1 || len(t.DecryptionProofs) != 1 ||
+		len(t.DecryptionFactors[0]) != len(mix.Ballots) ||
+		len(t.DecryptionProofs[0]) != len(mix.Ballots) {
+		return nil, ERR_INVALID_SHAPE
+	}
+
+	public, err := parsePoint(t.PublicKey.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]kyber.Point, len(mix.Ballots))
+	proofs := make([]*dleq.Proof, len(mix.Ballots))
+	for i, ballot := range mix.Ballots {
+		factor, err := parsePoint(t.DecryptionFactors[0][i])
+		if err != nil {
+			return nil, err
+		}
+		points[i] = crypto.Suite.Point().Sub(ballot.Beta, factor)
+
+		p := t.DecryptionProofs[0][i]
+		if p.Commitment == nil {
+			return nil, ERR_INVALID_SHAPE
+		}
+		proofs[i] = &dleq.Proof{}
+		if proofs[i].C, err = parseScalar(p.Challenge); err != nil {
+			return nil, err
+		}
+		if proofs[i].R, err = parseScalar(p.Response); err != nil {
+			return nil, err
+		}
+		if proofs[i].VG, err = parsePoint(p.Commitment.A); err != nil {
+			return nil, err
+		}
+		if proofs[i].VH, err = parsePoint(p.Commitment.B); err != nil {
+			return nil, err
+		}
+	}
+
+	return &chains.Partial{
+		Points: points,
+		Proofs: proofs,
+		Index:  index,
+		Public: public,
+		Node:   t.Email,
+	}, nil
+}
+
+// NewResult converts the tally results of the contests into a Helios result.
+// The counts of the final round are rounded to integers.
+func NewResult(results []*chains.Result) Result {
+	r := make(Result, len(results))
+	for i, result := range results {
+		r[i] = []int{}
+		if len(result.Rounds) == 0 {
+			continue
+		}
+
+		final := result.Rounds[len(result.Rounds)-1]
+		r[i] = make([]int, len(final.Counts))
+		for j, count := range final.Counts {
+			r[i][j] = int(math.Floor(count + 0.5))
+		}
+	}
+	return r
+}
+
+// Results converts a Helios result into single round tally results, one for
+// each question. The winner is the answer with the most votes, ties favour the
+// lower index.
+func (r Result) Results() ([]*chains.Result, error) {
+	if len(r) == 0 {
+		return nil, ERR_NO_QUESTION
+	}
+
+	results := make([]*chains.Result, len(r))
+	for i, question := range r {
+		counts := make([]float64, len(question))
+		winner := -1
+		for j, count := range question {
+			counts[j] = float64(count)
+			if winner < 0 || count > question[winner] {
+				winner = j
+			}
+		}
+
+		results[i] = &chains.Result{
+			Contest: uint32(i),
+			Rounds:  []*chains.Round{&chains.Round{Counts: counts}},
+		}
+		if winner >= 0 {
+			results[i].Winners = []uint32{uint32(winner)}
+			results[i].Rounds[0].Elected = results[i].Winners
+		}
+	}
+	return results, nil
+}
+
+// splitSchnorr converts a Schnorr signature R || s into a Helios proof.
+func splitSchnorr(signature []byte) []*Proof {
+	if len(signature) == 0 {
+		return []*Proof{}
+	}
+
+	n := crypto.Suite.PointLen()
+	if len(signature) < n {
+		return []*Proof{&Proof{Response: hex.EncodeToString(signature)}}
+	}
+	return []*Proof{&Proof{
+		Commitment: &Commitment{A: hex.EncodeToString(signature[:n])},
+		Response:   hex.EncodeToString(signature[n:]),
+	}}
+}
+
+// joinSchnorr reverses splitSchnorr.
+func joinSchnorr(proofs []*Proof) ([]byte, error) {
+	if len(proofs) == 0 {
+		return nil, nil
+	}
+
+	signature := make([]byte, 0)
+	if proofs[0].Commitment != nil {
+		commitment, err := hex.DecodeString(proofs[0].Commitment.A)
+		if err != nil {
+			return nil, err
+		}
+		signature = append(signature, commitment...)
+	}
+	response, err := hex.DecodeString(proofs[0].Response)
+	if err != nil {
+		return nil, err
+	}
+	return append(signature, response...), nil
+}
+
+// hash returns the Helios fingerprint of an object, the unpadded base64
+// encoding of the SHA-256 hash of its JSON serialisation.
+func hash(object interface{}) (string, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(data)
+	return base64.RawStdEncoding.EncodeToString(digest[:]), nil
+}
+
+// uuid derives a deterministic version 4 formatted UUID from some data.
+func uuid(data ...[]byte) string {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	digest := h.Sum(nil)
+	digest[6] = (digest[6] & 0x0f) | 0x40
+	digest[8] = (digest[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", digest[0:4], digest[4:6], digest[6:8],
+		digest[8:10], digest[10:16])
+}
+
+// voterUUID derives the UUID of a voter in an election.
+func voterUUID(e *chains.Election, user uint32) string {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, user)
+	return uuid(e.ID, buf)
+}
+
+func point(p kyber.Point) string {
+	if p == nil {
+		return ""
+	}
+	buf, _ := p.MarshalBinary()
+	return hex.EncodeToString(buf)
+}
+
+func scalar(s kyber.Scalar) string {
+	if s == nil {
+		return ""
+	}
+	buf, _ := s.MarshalBinary()
+	return hex.EncodeToString(buf)
+}
+
+func parsePoint(s string) (kyber.Point, error) {
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	p := crypto.Suite.Point()
+	if err = p.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func parseScalar(s string) (kyber.Scalar, error) {
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	x := crypto.Suite.Scalar()
+	if err = x.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
@@ -0,0 +1,187 @@
+package heliosjson
+
+import (
+	"testing"
+
+	"github.com/dedis/onet"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestElection(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{
+		Name:   "election",
+		Roster: roster,
+		End:    "2018-01-01",
+		Schema: &chains.Schema{Candidates: []string{"a", "b", "c"}, Method: chains.STV, Seats: 2},
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	e, err := NewElection(election).Election()
+	assert.Nil(t, err)
+	assert.Equal(t, election.Name, e.Name)
+	assert.Equal(t, election.End, e.End)
+	assert.Equal(t, election.Schema, e.Schema)
+	assert.True(t, election.Key.Equal(e.Key))
+}
+
+func TestElection_Contests(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{
+		Roster: roster,
+		Users:  []uint32{0, 1, 2},
+		Contests: []*chains.Contest{
+			&chains.Contest{Name: "a", Schema: &chains.Schema{Candidates: []string{"x"}, Seats: 1}},
+			&chains.Contest{Name: "b", Schema: &chains.Schema{Candidates: []string{"y"}, Seats: 1}},
+		},
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	h := NewElection(election)
+	assert.Equal(t, 2, len(h.Questions))
+
+	e, _ := h.Election()
+	assert.Equal(t, election.Contests, e.Contests)
+
+	ballots, _ := election.Ballots()
+	ballots[0].Contest = 1
+	votes, _ := NewCastVotes(election, ballots[:1])
+	assert.Equal(t, 2, len(votes[0].Vote.Answers))
+
+	ballot, _ := votes[0].Ballot(NewVoters(election))
+	assert.Equal(t, uint32(1), ballot.Contest)
+}
+
+func TestQuestion_Schema(t *testing.T) {
+	one, two := 1, 2
+
+	schema, _ := (&Question{Answers: []string{"a", "b"}, TallyType: HOMOMORPHIC, Max: &one}).Schema()
+	assert.Equal(t, uint32(chains.PLURALITY), schema.Method)
+
+	schema, _ = (&Question{Answers: []string{"a", "b"}, TallyType: HOMOMORPHIC, Max: &two}).Schema()
+	assert.Equal(t, uint32(chains.APPROVAL), schema.Method)
+	assert.Equal(t, uint32(2), schema.Seats)
+
+	schema, _ = (&Question{Answers: []string{"a", "b"}, TallyType: HOMOMORPHIC}).Schema()
+	assert.Equal(t, uint32(chains.APPROVAL), schema.Method)
+
+	_, err := (&Question{TallyType: "condorcet"}).Schema()
+	assert.Equal(t, ERR_UNKNOWN_TALLY, err)
+}
+
+func TestCastVotes(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Users: []uint32{0, 1, 2}}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	ballots, _ := election.Ballots()
+	ballots[0].Proof = make([]byte, 64)
+	ballots[0].Proof[0], ballots[0].Proof[63] = 1, 2
+
+	votes, _ := NewCastVotes(election, ballots)
+	voters := NewVoters(election)
+	for i, vote := range votes {
+		ballot, err := vote.Ballot(voters)
+		assert.Nil(t, err)
+		assert.Equal(t, ballots[i].User, ballot.User)
+		assert.True(t, ballots[i].Alpha.Equal(ballot.Alpha))
+		assert.True(t, ballots[i].Beta.Equal(ballot.Beta))
+	}
+
+	ballot, _ := votes[0].Ballot(voters)
+	assert.Equal(t, ballots[0].Proof, ballot.Proof)
+
+	_, err := votes[0].Ballot([]*Voter{})
+	assert.Equal(t, ERR_UNKNOWN_VOTER, err)
+
+	votes[0].Vote.Answers = nil
+	_, err = votes[0].Ballot(voters)
+	assert.Equal(t, ERR_INVALID_VOTE, err)
+}
+
+func TestMix_Contests(t *testing.T) {
+	ballots := make([]*chains.Ballot, 4)
+	for i := range ballots {
+		_, alpha := crypto.RandomKeyPair()
+		_, beta := crypto.RandomKeyPair()
+		ballots[i] = &chains.Ballot{Alpha: alpha, Beta: beta, Contest: uint32(i % 2), Weight: 1}
+	}
+	ballots[3].Weight = 2
+	mix := &chains.Mix{Ballots: ballots, Proofs: [][]byte{{1}, {2}, {3}}, Node: "node"}
+
+	decoded, err := NewMix(mix).Mix()
+	assert.Nil(t, err)
+	assert.Equal(t, mix.Segment(0), decoded.Segment(0))
+	assert.Equal(t, mix.Segment(1), decoded.Segment(1))
+	assert.Equal(t, mix.Weights(), decoded.Weights())
+	for i, ballot := range decoded.Ballots {
+		assert.Equal(t, ballots[i].Contest, ballot.Contest)
+		assert.True(t, ballots[i].Alpha.Equal(ballot.Alpha))
+	}
+	assert.Equal(t, mix.Proofs, decoded.Proofs)
+}
+
+func TestTrustee(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.DECRYPTED}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	mixes, _ := election.Mixes()
+	mix, _ := NewMix(mixes[len(mixes)-1]).Mix()
+	partials, _ := election.Partials()
+
+	for _, partial := range partials {
+		trustee, err := NewTrustee(election, mix, partial)
+		assert.Nil(t, err)
+
+		p, err := trustee.Partial(mix, partial.Index)
+		assert.Nil(t, err)
+		for i, ballot := range mix.Ballots {
+			assert.True(t, partial.Points[i].Equal(p.Points[i]))
+			assert.Nil(t, crypto.VerifyDecryption(p.Public, ballot.Alpha, ballot.Beta,
+				p.Points[i], p.Proofs[i]))
+		}
+	}
+
+	_, err := NewTrustee(election, &chains.Mix{}, partials[0])
+	assert.Equal(t, ERR_INVALID_SHAPE, err)
+}
+
+func TestResult(t *testing.T) {
+	result := &chains.Result{Rounds: []*chains.Round{
+		&chains.Round{Counts: []float64{1, 2, 3}},
+		&chains.Round{Counts: []float64{2, 4, 0}},
+	}}
+
+	r := NewResult([]*chains.Result{result, &chains.Result{}})
+	assert.Equal(t, Result{[]int{2, 4, 0}, []int{}}, r)
+
+	imported, _ := r.Results()
+	assert.Equal(t, []uint32{1}, imported[0].Winners)
+	assert.Equal(t, []float64{2, 4, 0}, imported[0].Rounds[0].Counts)
+	assert.Equal(t, uint32(1), imported[1].Contest)
+
+	_, err := Result{}.Results()
+	assert.Equal(t, ERR_NO_QUESTION, err)
+}
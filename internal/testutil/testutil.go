@@ -0,0 +1,16 @@
+// Package testutil provides helpers shared by the tests of the other packages.
+package testutil
+
+import (
+	"github.com/dedis/kyber"
+	"github.com/dedis/onet"
+)
+
+// Keys returns the private keys of the servers of a local test in roster order.
+func Keys(local *onet.LocalTest, servers []*onet.Server) []kyber.Scalar {
+	keys := make([]kyber.Scalar, len(servers))
+	for i, server := range servers {
+		keys[i] = local.GetPrivate(server)
+	}
+	return keys
+}
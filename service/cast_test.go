@@ -12,6 +12,7 @@ import (
 	"github.com/qantik/nevv/api"
 	"github.com/qantik/nevv/chains"
 	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
 )
 
 func TestCast_InvalidElectionID(t *testing.T) {
@@ -60,7 +61,7 @@ func TestCast_ElectionAlreadyClosed(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.SHUFFLED,
 	}
-	_ = election.GenChain(3)
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
 
 	_, err := s.Cast(&api.Cast{Token: "0", ID: election.ID})
 	assert.Equal(t, ERR_ALREADY_CLOSED, err)
@@ -71,7 +72,7 @@ func TestCast_ElectionAlreadyClosed(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.DECRYPTED,
 	}
-	_ = election.GenChain(3)
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
 
 	_, err = s.Cast(&api.Cast{Token: "0", ID: election.ID})
 	assert.Equal(t, ERR_ALREADY_CLOSED, err)
@@ -93,7 +94,8 @@ func TestCast_Full(t *testing.T) {
 	}
 	_ = election.GenChain(3)
 
-	ballot := &chains.Ballot{User: 1000}
+	alpha, beta := crypto.Encrypt(election.Key, []byte{0})
+	ballot := &chains.Ballot{User: 1000, Alpha: alpha, Beta: beta}
 	r, _ := s.Cast(&api.Cast{Token: "1000", ID: election.ID, Ballot: ballot})
 	assert.NotNil(t, r)
 
@@ -102,3 +104,136 @@ func TestCast_Full(t *testing.T) {
 	_, blob, _ := network.Unmarshal(chain.Update[len(chain.Update)-1].Data, crypto.Suite)
 	assert.Equal(t, ballot.User, blob.(*chains.Ballot).User)
 }
+
+func TestCast_NotEligible(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["1000"] = &stamp{user: 1000}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{1000, 1001},
+		Stage:   chains.RUNNING,
+		Contests: []*chains.Contest{
+			&chains.Contest{Name: "a"},
+			&chains.Contest{Name: "b", Users: []uint32{1001}},
+		},
+	}
+	_ = election.GenChain(3)
+
+	alpha, beta := crypto.Encrypt(election.Key, []byte{0})
+	ballot := &chains.Ballot{User: 1000, Alpha: alpha, Beta: beta, Contest: 1}
+	_, err := s.Cast(&api.Cast{Token: "1000", ID: election.ID, Ballot: ballot})
+	assert.Equal(t, ERR_NOT_ELIGIBLE, err)
+
+	ballot.Contest = 2
+	_, err = s.Cast(&api.Cast{Token: "1000", ID: election.ID, Ballot: ballot})
+	assert.Equal(t, ERR_NOT_ELIGIBLE, err)
+
+	ballot.Contest = 0
+	_, err = s.Cast(&api.Cast{Token: "1000", ID: election.ID, Ballot: ballot})
+	assert.Nil(t, err)
+}
+
+func TestCast_Weight(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["1000"] = &stamp{user: 1000}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{1000},
+		Weights: []uint32{7},
+		Stage:   chains.RUNNING,
+	}
+	_ = election.GenChain(3)
+
+	alpha, beta := crypto.Encrypt(election.Key, []byte{0})
+	ballot := &chains.Ballot{User: 1000, Alpha: alpha, Beta: beta, Weight: 100}
+	_, _ = s.Cast(&api.Cast{Token: "1000", ID: election.ID, Ballot: ballot})
+
+	box, _ := election.Box()
+	assert.Equal(t, uint32(7), box.Ballots[len(box.Ballots)-1].Weight)
+}
+
+func TestCast_Rejected(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["1000"] = &stamp{user: 1000}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{1000},
+		Stage:   chains.RUNNING,
+	}
+	_ = election.GenChain(3)
+
+	ballot := &chains.Ballot{User: 1000}
+	_, err := s.Cast(&api.Cast{Token: "1000", ID: election.ID, Ballot: ballot})
+	assert.Equal(t, chains.INVALID_POINT, err)
+
+	box, _ := election.Box()
+	ballot = &chains.Ballot{User: 1000, Alpha: box.Ballots[0].Alpha, Beta: box.Ballots[0].Beta}
+	_, err = s.Cast(&api.Cast{Token: "1000", ID: election.ID, Ballot: ballot})
+	assert.Equal(t, chains.DUPLICATE_CIPHERTEXT, err)
+}
+
+func TestCast_AlreadyCast(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.RUNNING,
+		Policy:  chains.SINGLE_BALLOT,
+	}
+	_ = election.GenChain(3)
+
+	ballot := &chains.Ballot{User: 0}
+	_, err := s.Cast(&api.Cast{Token: "0", ID: election.ID, Ballot: ballot})
+	assert.Equal(t, ERR_ALREADY_CAST, err)
+}
+
+func TestCast_NotOwner(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["1000"] = &stamp{user: 1000}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{1000, 1001},
+		Weights: []uint32{1, 9},
+		Stage:   chains.RUNNING,
+	}
+	_ = election.GenChain(3)
+
+	_, err := s.Cast(&api.Cast{Token: "1000", ID: election.ID})
+	assert.Equal(t, ERR_NO_BALLOT, err)
+
+	alpha, beta := crypto.Encrypt(election.Key, []byte{0})
+	ballot := &chains.Ballot{User: 1001, Alpha: alpha, Beta: beta}
+	_, err = s.Cast(&api.Cast{Token: "1000", ID: election.ID, Ballot: ballot})
+	assert.Equal(t, ERR_NOT_OWNER, err)
+}
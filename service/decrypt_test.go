@@ -11,6 +11,7 @@ import (
 	"github.com/qantik/nevv/chains"
 	"github.com/qantik/nevv/crypto"
 	"github.com/qantik/nevv/dkg"
+	"github.com/qantik/nevv/internal/testutil"
 )
 
 func TestDecrypt_UserNotLoggedIn(t *testing.T) {
@@ -99,12 +100,32 @@ func TestDecrypt_ElectionClosed(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.DECRYPTED,
 	}
-	_ = election.GenChain(3)
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
 
 	_, err := s.Decrypt(&api.Decrypt{Token: "0", ID: election.ID})
 	assert.Equal(t, ERR_ALREADY_DECRYPTED, err)
 }
 
+func TestDecrypt_NoSecret(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.SHUFFLED,
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	_, err := s.Decrypt(&api.Decrypt{Token: "0", ID: election.ID})
+	assert.Equal(t, ERR_NO_SECRET, err)
+}
+
 func TestDecrypt_Full(t *testing.T) {
 	local := onet.NewLocalTest(crypto.Suite)
 	defer local.CloseAll()
@@ -121,11 +142,15 @@ func TestDecrypt_Full(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.SHUFFLED,
 	}
-	dkgs := election.GenChain(3)
-	s0.secrets[election.ID.Short()], _ = dkg.NewSharedSecret(dkgs[0])
-	s1.secrets[election.ID.Short()], _ = dkg.NewSharedSecret(dkgs[1])
-	s2.secrets[election.ID.Short()], _ = dkg.NewSharedSecret(dkgs[2])
+	dkgs := election.GenChain(3, testutil.Keys(local, nodes)...)
+	for i, s := range []*Service{s0, s1, s2} {
+		secret, _ := dkg.NewSharedSecret(dkgs[i])
+		s.secrets.add(election.ID, secret)
+	}
 
 	r, _ := s0.Decrypt(&api.Decrypt{Token: "0", ID: election.ID})
 	assert.NotNil(t, r)
+
+	job := await(s0, r.Job)
+	assert.Equal(t, uint32(api.JOB_DONE), job.Status)
 }
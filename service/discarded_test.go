@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dedis/onet"
+
+	"github.com/qantik/nevv/api"
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+)
+
+func TestGetDiscarded_UserNotAdmin(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: false}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.RUNNING,
+	}
+	_ = election.GenChain(3)
+
+	_, err := s.GetDiscarded(&api.GetDiscarded{Token: "0", ID: election.ID})
+	assert.Equal(t, ERR_NOT_ADMIN, err)
+}
+
+func TestGetDiscarded_Full(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.RUNNING,
+		Policy:  chains.FIRST_WINS,
+	}
+	_ = election.GenChain(3)
+	_ = election.Store(&chains.Ballot{User: 1})
+	_ = election.Store(&chains.Ballot{User: 1})
+
+	r, _ := s.GetDiscarded(&api.GetDiscarded{Token: "0", ID: election.ID})
+	assert.Equal(t, uint32(chains.FIRST_WINS), r.Policy)
+	assert.Equal(t, uint32(2), r.Discarded)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dedis/onet"
+
+	"github.com/qantik/nevv/api"
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/export"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestExport_UserNotLoggedIn(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+
+	_, err := s.Export(&api.Export{Token: "0", ID: []byte{}})
+	assert.Equal(t, ERR_NOT_LOGGED_IN, err)
+}
+
+func TestExport_Full(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: false}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.SHUFFLED,
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	r, _ := s.Export(&api.Export{Token: "0", ID: election.ID})
+	a, err := export.Decode(&export.Bundle{Files: r.Files})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(a.Box.Ballots))
+	assert.Equal(t, 3, len(a.Mixes))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dedis/onet"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/api"
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+)
+
+func TestGetJob_NotAdmin(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: false}
+
+	_, err := s.GetJob(&api.GetJob{Token: "0"})
+	assert.Equal(t, ERR_NOT_ADMIN, err)
+}
+
+func TestGetJob_Unknown(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	_, err := s.GetJob(&api.GetJob{Token: "0", ID: "job"})
+	assert.Equal(t, ERR_UNKNOWN_JOB, err)
+}
+
+func TestGetJob_Full(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.RUNNING,
+	}
+	_ = election.GenChain(3)
+
+	r, _ := s.Shuffle(&api.Shuffle{Token: "0", ID: election.ID})
+	await(s, r.Job)
+
+	reply, _ := s.GetJob(&api.GetJob{Token: "0", ID: r.Job})
+	assert.Equal(t, "shuffle", reply.Job.Kind)
+	assert.Equal(t, uint32(api.JOB_DONE), reply.Job.Status)
+	assert.Equal(t, uint32(3), reply.Job.Mixes)
+	assert.Equal(t, uint32(0), reply.Job.Partials)
+}
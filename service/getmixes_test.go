@@ -10,6 +10,7 @@ import (
 	"github.com/qantik/nevv/api"
 	"github.com/qantik/nevv/chains"
 	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
 )
 
 func TestGetMixes_UserNotLoggedIn(t *testing.T) {
@@ -38,7 +39,7 @@ func TestGetMixes_UserNotPart(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.SHUFFLED,
 	}
-	_ = election.GenChain(3)
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
 
 	_, err := s.GetMixes(&api.GetMixes{Token: "1", ID: election.ID})
 	assert.NotNil(t, ERR_NOT_PART, err)
@@ -78,7 +79,7 @@ func TestGetMixes_Full(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.SHUFFLED,
 	}
-	_ = election.GenChain(10)
+	_ = election.GenChain(10, testutil.Keys(local, nodes)...)
 
 	r, _ := s.GetMixes(&api.GetMixes{Token: "0", ID: election.ID})
 	assert.Equal(t, 3, len(r.Mixes))
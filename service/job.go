@@ -0,0 +1,82 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dedis/cothority/skipchain"
+
+	"github.com/qantik/nevv/api"
+)
+
+const (
+	// baseTimeout is the minimum time a protocol is given to finish.
+	baseTimeout = 5 * time.Second
+	// ballotTimeout is the additional time per ballot and node.
+	ballotTimeout = 20 * time.Millisecond
+)
+
+// jobs is the log of protocols running in the background.
+type jobs struct {
+	sync.Mutex
+
+	// log is a map from job identifier to job.
+	log map[string]*api.Job
+}
+
+// add registers a new running job for an election with a deadline.
+func (j *jobs) add(kind string, id skipchain.SkipBlockID, timeout time.Duration) string {
+	j.Lock()
+	defer j.Unlock()
+
+	now := time.Now()
+	job := &api.Job{
+		ID:       nonce(16),
+		Kind:     kind,
+		Election: id,
+		Status:   api.JOB_RUNNING,
+		Start:    now.UnixNano(),
+		Deadline: now.Add(timeout).UnixNano(),
+	}
+	j.log[job.ID] = job
+	return job.ID
+}
+
+// get returns a copy of a job.
+func (j *jobs) get(id string) (*api.Job, bool) {
+	j.Lock()
+	defer j.Unlock()
+
+	job, found := j.log[id]
+	if !found {
+		return nil, false
+	}
+	copy := *job
+	return &copy, true
+}
+
+// update applies a change to a job.
+func (j *jobs) update(id string, change func(*api.Job)) {
+	j.Lock()
+	defer j.Unlock()
+
+	if job, found := j.log[id]; found {
+		change(job)
+	}
+}
+
+// finish concludes a job as done or, if err is not nil, as failed.
+func (j *jobs) finish(id string, err error) {
+	j.update(id, func(job *api.Job) {
+		job.Status, job.End = api.JOB_DONE, time.Now().UnixNano()
+		if err != nil {
+			job.Status, job.Error = api.JOB_FAILED, err.Error()
+		}
+	})
+}
+
+// timeout estimates how long a protocol may run given the number of ballots
+// each node processes and the number of nodes working one after the other.
+func timeout(ballots, nodes int) time.Duration {
+	return time.Duration(nodes) * (baseTimeout + time.Duration(ballots)*ballotTimeout)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/api"
+)
+
+// await polls a job until it is no longer running.
+func await(s *Service, id string) *api.Job {
+	for {
+		if job, _ := s.jobs.get(id); job == nil || job.Status != api.JOB_RUNNING {
+			return job
+		}
+		<-time.After(100 * time.Millisecond)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	j := &jobs{log: make(map[string]*api.Job)}
+
+	id := j.add("shuffle", []byte{1}, time.Second)
+	job, _ := j.get(id)
+	assert.Equal(t, "shuffle", job.Kind)
+	assert.Equal(t, uint32(api.JOB_RUNNING), job.Status)
+	assert.Equal(t, time.Second.Nanoseconds(), job.Deadline-job.Start)
+
+	j.update(id, func(job *api.Job) { job.Node = "node" })
+	job, _ = j.get(id)
+	assert.Equal(t, "node", job.Node)
+
+	j.finish(id, nil)
+	job, _ = j.get(id)
+	assert.Equal(t, uint32(api.JOB_DONE), job.Status)
+	assert.NotEqual(t, int64(0), job.End)
+
+	j.finish(id, errors.New("error"))
+	job, _ = j.get(id)
+	assert.Equal(t, uint32(api.JOB_FAILED), job.Status)
+	assert.Equal(t, "error", job.Error)
+
+	_, found := j.get("")
+	assert.False(t, found)
+}
+
+func TestTimeout(t *testing.T) {
+	assert.Equal(t, baseTimeout, timeout(0, 1))
+	assert.Equal(t, 3*(baseTimeout+100*ballotTimeout), timeout(100, 3))
+}
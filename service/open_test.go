@@ -50,6 +50,102 @@ func TestOpen_InvalidMasterID(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestOpen_InvalidWeights(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{Users: []uint32{0, 1}, Weights: []uint32{1}}
+	_, err := s.Open(&api.Open{Token: "0", Election: election})
+	assert.Equal(t, ERR_INVALID_WEIGHTS, err)
+
+	election.Weights = []uint32{1, 0}
+	_, err = s.Open(&api.Open{Token: "0", Election: election})
+	assert.Equal(t, ERR_INVALID_WEIGHTS, err)
+
+	election.Users, election.Weights = []uint32{0, 1, 2, 3}, []uint32{1, 1, 1, 2}
+	_, err = s.Open(&api.Open{Token: "0", Election: election})
+	assert.Equal(t, ERR_SMALL_CLASS, err)
+}
+
+func TestOpen_InvalidMixes(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	master := &chains.Master{Roster: roster}
+	master.GenChain(nil)
+
+	election := &chains.Election{MinMixes: 2}
+	_, err := s.Open(&api.Open{Token: "0", ID: master.ID, Election: election})
+	assert.Equal(t, ERR_INVALID_MIXES, err)
+
+	election.MinMixes = 4
+	_, err = s.Open(&api.Open{Token: "0", ID: master.ID, Election: election})
+	assert.Equal(t, ERR_INVALID_MIXES, err)
+}
+
+func TestOpen_InvalidThreshold(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	master := &chains.Master{Roster: roster}
+	master.GenChain(nil)
+
+	election := &chains.Election{}
+	_, err := s.Open(&api.Open{Token: "0", ID: master.ID, Election: election, Threshold: 2})
+	assert.Equal(t, ERR_INVALID_THRESHOLD, err)
+
+	_, err = s.Open(&api.Open{Token: "0", ID: master.ID, Election: election, Threshold: 5})
+	assert.Equal(t, ERR_INVALID_THRESHOLD, err)
+
+	election.MinMixes = 3
+	_, err = s.Open(&api.Open{Token: "0", ID: master.ID, Election: election, Threshold: 4})
+	assert.Equal(t, ERR_INVALID_MIXES, err)
+}
+
+func TestOpen_UnknownMixnet(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	master := &chains.Master{Roster: roster}
+	master.GenChain(nil)
+
+	election := &chains.Election{Mixnet: "bayer-groth"}
+	_, err := s.Open(&api.Open{Token: "0", ID: master.ID, Election: election})
+	assert.Equal(t, crypto.ERR_UNKNOWN_PROVER, err)
+}
+
+func TestOpen_UnknownPolicy(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	master := &chains.Master{Roster: roster}
+	master.GenChain(nil)
+
+	election := &chains.Election{Policy: chains.SINGLE_BALLOT + 1}
+	_, err := s.Open(&api.Open{Token: "0", ID: master.ID, Election: election})
+	assert.Equal(t, ERR_INVALID_POLICY, err)
+}
+
 func TestOpen_CloseConnection(t *testing.T) {
 	local := onet.NewLocalTest(crypto.Suite)
 
@@ -80,10 +176,20 @@ func TestOpen_Full(t *testing.T) {
 	r, _ := s.Open(&api.Open{Token: "0", ID: master.ID, Election: election})
 	assert.NotNil(t, r)
 
+	job := await(s, r.Job)
+	assert.Equal(t, uint32(api.JOB_DONE), job.Status)
+
 	client := skipchain.NewClient()
 	chain, _ := client.GetUpdateChain(roster, r.ID)
 	_, blob, _ := network.Unmarshal(chain.Update[1].Data, crypto.Suite)
 	assert.Equal(t, r.ID, blob.(*chains.Election).ID)
-
-	assert.Equal(t, r.Key, s.secrets[r.ID.Short()].X)
+	assert.Equal(t, uint32(3), blob.(*chains.Election).Threshold)
+	assert.Nil(t, blob.(*chains.Election).VerifyTranscript())
+	_, blob, _ = network.Unmarshal(chain.Update[2].Data, crypto.Suite)
+	assert.Equal(t, "dkg", blob.(*chains.Timing).Name)
+
+	e, _ := chains.FetchElection(roster, r.ID)
+	secret, found := s.secrets.get(e)
+	assert.True(t, found)
+	assert.Equal(t, job.Key, secret.X)
 }
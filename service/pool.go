@@ -0,0 +1,43 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/dedis/cothority/skipchain"
+	"github.com/dedis/kyber"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+// pools holds the re-encryption factors precomputed by this node while the
+// elections are open, so that shuffling at close only permutes and combines.
+type pools struct {
+	sync.Mutex
+
+	// log is a map from election identifier to factor pool.
+	log map[string]*crypto.Pool
+}
+
+// fill starts precomputing n factors under the key of an election in the
+// background. The size is capped at the maximum number of ballots.
+func (p *pools) fill(id skipchain.SkipBlockID, key kyber.Point, n int) {
+	if n > maxBallots {
+		n = maxBallots
+	}
+
+	pool := crypto.NewPool(key)
+	p.Lock()
+	p.log[id.Short()] = pool
+	p.Unlock()
+	go pool.Fill(n)
+}
+
+// take removes and returns the pool of an election, or nil if there is none.
+func (p *pools) take(id skipchain.SkipBlockID) *crypto.Pool {
+	p.Lock()
+	defer p.Unlock()
+
+	pool := p.log[id.Short()]
+	delete(p.log, id.Short())
+	return pool
+}
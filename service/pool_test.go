@@ -0,0 +1,26 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dedis/cothority/skipchain"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+func TestPools(t *testing.T) {
+	p := &pools{log: make(map[string]*crypto.Pool)}
+	id := skipchain.SkipBlockID([]byte{1})
+
+	_, X := crypto.RandomKeyPair()
+	p.fill(id, X, 3)
+	for i := 0; i < 50 && p.log[id.Short()].Size() < 3; i++ {
+		<-time.After(10 * time.Millisecond)
+	}
+
+	pool := p.take(id)
+	assert.Equal(t, 3, pool.Size())
+	assert.Nil(t, p.take(id))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/qantik/nevv/api"
 	"github.com/qantik/nevv/chains"
 	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
 )
 
 func TestReconstruct_UserNotLoggedIn(t *testing.T) {
@@ -39,12 +40,33 @@ func TestReconstruct_ElectionNotDecrypted(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.SHUFFLED,
 	}
-	_ = election.GenChain(3)
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
 
 	_, err := s.Reconstruct(&api.Reconstruct{Token: "0", ID: election.ID})
 	assert.Equal(t, ERR_NOT_DECRYPTED, err)
 }
 
+func TestReconstruct_WithoutSecret(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.DECRYPTED,
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	r, err := s.Reconstruct(&api.Reconstruct{Token: "0", ID: election.ID})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(r.Points))
+}
+
 func TestReconstruct_Full(t *testing.T) {
 	local := onet.NewLocalTest(crypto.Suite)
 	defer local.CloseAll()
@@ -59,10 +81,11 @@ func TestReconstruct_Full(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.DECRYPTED,
 	}
-	_ = election.GenChain(7)
+	_ = election.GenChain(7, testutil.Keys(local, nodes)...)
 
 	r, _ := s.Reconstruct(&api.Reconstruct{Token: "0", ID: election.ID})
 	assert.Equal(t, 7, len(r.Points))
+	assert.Equal(t, 0, len(r.Rejected))
 
 	messages := make([]int, 7)
 	for i, point := range r.Points {
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/network"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/api"
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/decrypt"
+	"github.com/qantik/nevv/dkg"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestReshare_UserNotAdmin(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: false}
+
+	_, err := s.Reshare(&api.Reshare{Token: "0"})
+	assert.Equal(t, ERR_NOT_ADMIN, err)
+}
+
+func TestReshare_ElectionClosed(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.SHUFFLED,
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	_, err := s.Reshare(&api.Reshare{Token: "0", ID: election.ID, Roster: roster})
+	assert.Equal(t, ERR_ALREADY_CLOSED, err)
+}
+
+func TestReshare_InvalidRoster(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  onet.NewRoster(roster.List[:3]),
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.RUNNING,
+	}
+	dkgs := election.GenChain(3)
+	_, err := s.Reshare(&api.Reshare{Token: "0", ID: election.ID, Roster: election.Roster})
+	assert.Equal(t, ERR_NO_SECRET, err)
+
+	secret, _ := dkg.NewSharedSecret(dkgs[0])
+	s.secrets.add(election.ID, secret)
+	_, err = s.Reshare(&api.Reshare{Token: "0", ID: election.ID, Roster: roster})
+	assert.Equal(t, ERR_INVALID_ROSTER, err)
+
+	_, err = s.Reshare(&api.Reshare{Token: "0", ID: election.ID,
+		Roster: onet.NewRoster(roster.List[1:3])})
+	assert.Equal(t, ERR_INVALID_ROSTER, err)
+
+	_, err = s.Reshare(&api.Reshare{Token: "0", ID: election.ID,
+		Roster: election.Roster, Threshold: 1})
+	assert.Equal(t, ERR_INVALID_THRESHOLD, err)
+}
+
+func TestReshare_NewNode(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  onet.NewRoster(roster.List[:3]),
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.RUNNING,
+	}
+	dkgs := election.GenChain(3)
+	secret, _ := dkg.NewSharedSecret(dkgs[0])
+	s.secrets.add(election.ID, secret)
+
+	joined := onet.NewRoster([]*network.ServerIdentity{roster.List[0], roster.List[1], roster.List[3]})
+	_, err := s.Reshare(&api.Reshare{Token: "0", ID: election.ID, Roster: joined})
+	assert.Equal(t, ERR_INVALID_ROSTER, err)
+
+	_, err = reshareable(election, joined, 0)
+	assert.Equal(t, ERR_INVALID_ROSTER, err)
+}
+
+func TestReshare_Full(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+	services := local.GetServices(nodes, serviceID)
+	s := services[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0, 1, 2},
+		Stage:   chains.RUNNING,
+	}
+	dkgs := election.GenChain(3)
+	olds := make([]*dkg.SharedSecret, len(services))
+	for i, service := range services {
+		olds[i], _ = dkg.NewSharedSecret(dkgs[i])
+		service.(*Service).secrets.add(election.ID, olds[i])
+	}
+
+	sub := onet.NewRoster(roster.List[:3])
+	r, err := s.Reshare(&api.Reshare{Token: "0", ID: election.ID, Roster: sub, Threshold: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(api.JOB_DONE), await(s, r.Job).Status)
+
+	e, _ := chains.FetchElection(roster, election.ID)
+	assert.Equal(t, chains.RUNNING, int(e.Stage))
+	assert.Equal(t, 3, len(e.Roster.List))
+	assert.Equal(t, 2, e.RequiredPartials())
+	assert.True(t, election.Key.Equal(e.Key))
+	assert.Nil(t, e.VerifyTranscript())
+
+	secrets := make([]*dkg.SharedSecret, 3)
+	for i := range secrets {
+		secrets[i], _ = services[i].(*Service).secrets.get(e)
+		assert.Equal(t, i, secrets[i].Index)
+		assert.True(t, election.Key.Equal(secrets[i].X))
+	}
+	_, found := s.secrets.get(election)
+	assert.False(t, found)
+	_, found = services[3].(*Service).secrets.get(e)
+	assert.False(t, found)
+
+	box, _ := e.Box()
+	mix, _ := chains.Shuffle(crypto.Neff{}, e.Key, box.Ballots, nil)
+	partials, _ := decrypt.Simulate(secrets[1:], mix)
+	points, _, err := decrypt.Reconstruct(secrets[0].Poly(), mix, partials, 2, 3)
+	assert.Nil(t, err)
+
+	plaintexts := make([]int, len(points))
+	for i, point := range points {
+		data, _ := point.Data()
+		plaintexts[i] = int(data[0])
+	}
+	sort.Ints(plaintexts)
+	assert.Equal(t, []int{0, 1, 2}, plaintexts)
+}
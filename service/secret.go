@@ -0,0 +1,57 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/dedis/cothority/skipchain"
+	"github.com/dedis/kyber"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/dkg"
+)
+
+// secrets holds the DKG shares of this node. A share produced by resharing is
+// only staged until the roster change committing to it is on the skipchain.
+type secrets struct {
+	sync.Mutex
+
+	// log is a map from election identifier to the candidate shares.
+	log map[string][]*dkg.SharedSecret
+}
+
+// add stages a share of an election.
+func (s *secrets) add(id skipchain.SkipBlockID, secret *dkg.SharedSecret) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.log[id.Short()] = append(s.log[id.Short()], secret)
+}
+
+// get returns the share committed to by the DKG transcript of an election
+// and drops the shares it supersedes.
+func (s *secrets) get(election *chains.Election) (*dkg.SharedSecret, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	id := election.ID.Short()
+	for i, secret := range s.log[id] {
+		if equal(secret.Commits, election.Commits) {
+			s.log[id] = s.log[id][i:]
+			return secret, true
+		}
+	}
+	return nil, false
+}
+
+// equal checks that two lists of points are the same.
+func equal(a, b []kyber.Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
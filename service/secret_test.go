@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dedis/cothority/skipchain"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/dkg"
+)
+
+func TestSecrets(t *testing.T) {
+	s := &secrets{log: make(map[string][]*dkg.SharedSecret)}
+	id := skipchain.SkipBlockID([]byte{1})
+
+	olds, _ := dkg.Simulate(3, 2)
+	news, _ := dkg.Simulate(3, 2)
+	old, _ := dkg.NewSharedSecret(olds[0])
+	renewed, _ := dkg.NewSharedSecret(news[0])
+
+	s.add(id, old)
+	s.add(id, renewed)
+
+	secret, found := s.get(&chains.Election{ID: id, Commits: old.Commits})
+	assert.True(t, found)
+	assert.Equal(t, old, secret)
+
+	secret, found = s.get(&chains.Election{ID: id, Commits: renewed.Commits})
+	assert.True(t, found)
+	assert.Equal(t, renewed, secret)
+
+	_, found = s.get(&chains.Election{ID: id, Commits: old.Commits})
+	assert.False(t, found)
+}
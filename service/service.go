@@ -6,7 +6,6 @@ import (
 
 	"github.com/dedis/cothority/skipchain"
 	"github.com/dedis/kyber"
-	"github.com/dedis/kyber/share"
 	"github.com/dedis/onet"
 	"github.com/dedis/onet/log"
 	"github.com/dedis/onet/network"
@@ -16,7 +15,9 @@ import (
 	"github.com/qantik/nevv/crypto"
 	"github.com/qantik/nevv/decrypt"
 	"github.com/qantik/nevv/dkg"
+	"github.com/qantik/nevv/export"
 	"github.com/qantik/nevv/shuffle"
+	"github.com/qantik/nevv/tally"
 )
 
 // Name is the identifier of the service (application name).
@@ -32,15 +33,37 @@ var (
 
 	ERR_NOT_SHUFFLED      = errors.New("Election has not been shuffled yet")
 	ERR_NOT_DECRYPTED     = errors.New("Election has not been decrypted yet")
+	ERR_NOT_TALLIED       = errors.New("Election has not been tallied yet")
 	ERR_ALREADY_SHUFFLED  = errors.New("Election has already been shuffled")
 	ERR_ALREADY_DECRYPTED = errors.New("Election has already been decrypted")
+	ERR_ALREADY_TALLIED   = errors.New("Election has already been tallied")
 	ERR_ALREADY_CLOSED    = errors.New("Election has already been closed")
+	ERR_ALREADY_CAST      = errors.New("User has already cast a ballot")
 	ERR_CORRUPT           = errors.New("Election skipchain is corrupt")
+	ERR_NO_SECRET         = errors.New("Election has no shared secret on this node")
+	ERR_INVALID_WEIGHTS   = errors.New("Weights must be positive and match the voters")
+	ERR_SMALL_CLASS       = errors.New("Weights must be shared by enough voters of a contest")
+	ERR_INVALID_QUORUM    = errors.New("Quorum must be a percentage")
+	ERR_INVALID_POLICY    = errors.New("Re-voting policy is unknown")
+	ERR_INVALID_MIXES     = errors.New("Minimum mixes must be between threshold and roster size")
+	ERR_INVALID_THRESHOLD = errors.New("Threshold must be a majority of the roster")
+	ERR_INVALID_ROSTER    = errors.New("Roster must be a subset of the election one with this node")
+	ERR_NOT_ENOUGH_MIXES  = errors.New("Not enough mixes have been stored")
+	ERR_QUORUM            = errors.New("Turnout is below the quorum, election is void")
+	ERR_VOID              = errors.New("Election is void")
+	ERR_COMPLAINT         = errors.New("Shuffle aborted by a complaint")
+	ERR_NOT_ELIGIBLE      = errors.New("User is not eligible in this contest")
+	ERR_NO_BALLOT         = errors.New("Ballot is missing")
+	ERR_NOT_OWNER         = errors.New("Ballot is not cast by the logged in user")
 
 	ERR_PROTOCOL_UNKNOWN = errors.New("Protocol unknown")
 	ERR_PROTOCOL_TIMEOUT = errors.New("Protocol timeout")
+	ERR_UNKNOWN_JOB      = errors.New("Job unknown")
 )
 
+// maxBallots is the maximum number of ballots an election skipchain can hold.
+const maxBallots = 100000
+
 // serviceID is the onet identifier.
 var serviceID onet.ServiceID
 
@@ -48,16 +71,27 @@ var serviceID onet.ServiceID
 type Service struct {
 	*onet.ServiceProcessor
 
-	secrets map[string]*dkg.SharedSecret // secrets is map a of DKG products.
+	validators []chains.Validator // validators are run on every cast ballot.
 
-	state *state       // state is the log of currently logged in users.
-	node  *onet.Roster // nodes is a unitary roster.
-	pin   string       // pin is the current service number.
+	secrets *secrets     // secrets are the DKG shares of this node.
+	state   *state       // state is the log of currently logged in users.
+	jobs    *jobs        // jobs is the log of background protocols.
+	pools   *pools       // pools are the precomputed re-encryption factors.
+	node    *onet.Roster // nodes is a unitary roster.
+	pin     string       // pin is the current service number.
 }
 
 // synchronizer is broadcasted to all roster nodes before every protocol.
 type synchronizer struct {
 	ID skipchain.SkipBlockID
+
+	// Capacity is the expected number of ballots, set for the DKG so that
+	// nodes can precompute re-encryption factors while the election is open.
+	Capacity uint32
+
+	// Roster and Threshold of the new shares, set when resharing.
+	Roster    *onet.Roster
+	Threshold uint32
 }
 
 func init() {
@@ -97,48 +131,77 @@ func (s *Service) Open(req *api.Open) (*api.OpenReply, error) {
 		return nil, err
 	}
 
+	if err := weigh(req.Election); err != nil {
+		return nil, err
+	} else if req.Election.Quorum > 100 {
+		return nil, ERR_INVALID_QUORUM
+	} else if req.Election.Policy > chains.SINGLE_BALLOT {
+		return nil, ERR_INVALID_POLICY
+	} else if _, err := req.Election.Prover(); err != nil {
+		return nil, err
+	}
+
 	master, err := chains.FetchMaster(s.node, req.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	size, threshold := len(master.Roster.List), int(req.Threshold)
+	if threshold == 0 {
+		threshold = dkg.Threshold(size)
+	} else if !dkg.ValidThreshold(threshold, size) {
+		return nil, ERR_INVALID_THRESHOLD
+	}
+	if m := int(req.Election.MinMixes); m != 0 && (m < threshold || m > size) {
+		return nil, ERR_INVALID_MIXES
+	}
+
 	genesis, err := chains.New(master.Roster, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	size := len(master.Roster.List)
 	tree := master.Roster.GenerateNaryTreeWithRoot(size, s.ServerIdentity())
 	instance, err := s.CreateProtocol(dkg.Name, tree)
 	protocol := instance.(*dkg.SetupDKG)
+	protocol.Threshold = uint32(threshold)
 
-	config, _ := network.Marshal(&synchronizer{genesis.Hash})
+	capacity := req.Election.Capacity()
+	config, _ := network.Marshal(&synchronizer{ID: genesis.Hash, Capacity: uint32(capacity)})
 	protocol.SetConfig(&onet.GenericConfig{Data: config})
 
-	if err = protocol.Start(); err != nil {
-		return nil, err
-	}
-
-	select {
-	case <-protocol.Done:
-		secret, _ := protocol.SharedSecret()
+	start, limit := time.Now(), timeout(0, size)
+	job := s.jobs.add(dkg.Name, genesis.Hash, limit)
+	s.background(job, protocol, protocol.Done, limit, func(bool) error {
+		secret, err := protocol.SharedSecret()
+		if err != nil {
+			return err
+		}
 		req.Election.ID = genesis.Hash
 		req.Election.Roster = master.Roster
 		req.Election.Key = secret.X
-		s.secrets[genesis.Short()] = secret
+		req.Election.Threshold = uint32(threshold)
+		req.Election.Commits, req.Election.Shares = secret.Commits, secret.PublicShares(size)
+		if err := req.Election.VerifyTranscript(); err != nil {
+			return err
+		}
+		s.secrets.add(genesis.Hash, secret)
+		s.pools.fill(genesis.Hash, secret.X, capacity)
 
 		if err := req.Election.Store(req.Election); err != nil {
-			return nil, err
+			return err
 		}
-
-		if err = master.Store(&chains.Link{ID: genesis.Hash}); err != nil {
-			return nil, err
+		if err := req.Election.Store(timing("dkg", start)); err != nil {
+			return err
+		}
+		if err := master.Store(&chains.Link{ID: genesis.Hash}); err != nil {
+			return err
 		}
 
-		return &api.OpenReply{ID: genesis.Hash, Key: secret.X}, nil
-	case <-time.After(2 * time.Second):
-		return nil, ERR_PROTOCOL_TIMEOUT
-	}
+		s.jobs.update(job, func(j *api.Job) { j.Key = secret.X })
+		return nil
+	})
+	return &api.OpenReply{ID: genesis.Hash, Job: job}, nil
 }
 
 // Login message handler. Log potential user in state.
@@ -185,6 +248,31 @@ func (s *Service) Cast(req *api.Cast) (*api.CastReply, error) {
 		return nil, ERR_ALREADY_CLOSED
 	}
 
+	// Eligibility, re-voting and weight are decided for the logged in user.
+	if req.Ballot == nil {
+		return nil, ERR_NO_BALLOT
+	} else if stamp, _ := s.state.get(req.Token); stamp == nil || stamp.user != req.Ballot.User {
+		return nil, ERR_NOT_OWNER
+	}
+
+	if !election.Eligible(req.Ballot.User, req.Ballot.Contest) {
+		return nil, ERR_NOT_ELIGIBLE
+	}
+
+	if election.Policy == chains.SINGLE_BALLOT {
+		cast, err := election.HasCast(req.Ballot.User, req.Ballot.Contest)
+		if err != nil {
+			return nil, err
+		} else if cast {
+			return nil, ERR_ALREADY_CAST
+		}
+	}
+
+	req.Ballot.Weight = election.Weight(req.Ballot.User)
+	if err = election.Validate(req.Ballot, s.validators...); err != nil {
+		return nil, err
+	}
+
 	if err = election.Store(req.Ballot); err != nil {
 		return nil, err
 	}
@@ -207,6 +295,58 @@ func (s *Service) GetBox(req *api.GetBox) (*api.GetBoxReply, error) {
 	return &api.GetBoxReply{Box: box}, nil
 }
 
+// GetDiscarded message handler. Count ballots superseded by the re-voting policy.
+func (s *Service) GetDiscarded(req *api.GetDiscarded) (*api.GetDiscardedReply, error) {
+	election, err := s.vet(req.Token, req.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	discarded, err := election.Discarded()
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.GetDiscardedReply{Policy: election.Policy, Discarded: uint32(discarded)}, nil
+}
+
+// GetTurnout message handler. Count the distinct eligible voters who cast.
+// Only the creator of the election learns who voted.
+func (s *Service) GetTurnout(req *api.GetTurnout) (*api.GetTurnoutReply, error) {
+	stamp, found := s.state.get(req.Token)
+	if !found {
+		return nil, ERR_NOT_LOGGED_IN
+	}
+
+	election, err := chains.FetchElection(s.node, req.ID)
+	if err != nil {
+		return nil, err
+	} else if election.Stage == chains.CORRUPT {
+		return nil, ERR_CORRUPT
+	}
+
+	creator := stamp.admin && election.IsCreator(stamp.user)
+	if !creator && !election.IsUser(stamp.user) {
+		return nil, ERR_NOT_PART
+	}
+
+	voters, err := election.Turnout()
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &api.GetTurnoutReply{
+		Voted:  uint32(len(voters)),
+		Roll:   uint32(len(election.Users)),
+		Quorum: election.Quorum,
+		Void:   election.Stage == chains.VOID,
+	}
+	if creator {
+		reply.Voters = voters
+	}
+	return reply, nil
+}
+
 // GetMixes message handler. Vet all created mixes.
 func (s *Service) GetMixes(req *api.GetMixes) (*api.GetMixesReply, error) {
 	election, err := s.vet(req.Token, req.ID, false)
@@ -252,28 +392,55 @@ func (s *Service) Shuffle(req *api.Shuffle) (*api.ShuffleReply, error) {
 		return nil, err
 	}
 
-	if election.Stage >= chains.SHUFFLED {
+	if election.Stage == chains.VOID {
+		return nil, ERR_VOID
+	} else if election.Stage >= chains.SHUFFLED {
 		return nil, ERR_ALREADY_SHUFFLED
 	}
 
-	tree := election.Roster.GenerateNaryTreeWithRoot(1, s.ServerIdentity())
+	voters, err := election.Turnout()
+	if err != nil {
+		return nil, err
+	} else if !election.Quorate(len(voters)) {
+		void := &chains.Void{Turnout: uint32(len(voters)), Roll: uint32(len(election.Users))}
+		if err = election.Store(void); err != nil {
+			return nil, err
+		}
+		return nil, ERR_QUORUM
+	}
+
+	box, err := election.Box()
+	if err != nil {
+		return nil, err
+	} else if !box.Anonymous() {
+		return nil, ERR_SMALL_CLASS
+	}
+
+	size := len(election.Roster.List)
+	tree := election.Roster.GenerateNaryTreeWithRoot(size-1, s.ServerIdentity())
 	instance, _ := s.CreateProtocol(shuffle.Name, tree)
 	protocol := instance.(*shuffle.Protocol)
 	protocol.Election = election
+	protocol.Pool = s.pools.take(election.ID)
+	protocol.Timeout = timeout(len(box.Ballots), 1)
 
-	config, _ := network.Marshal(&synchronizer{election.ID})
+	config, _ := network.Marshal(&synchronizer{ID: election.ID})
 	protocol.SetConfig(&onet.GenericConfig{Data: config})
 
-	if err = protocol.Start(); err != nil {
-		return nil, err
-	}
-
-	select {
-	case <-protocol.Finished:
-		return &api.ShuffleReply{}, nil
-	case <-time.After(5 * time.Second):
-		return nil, ERR_PROTOCOL_TIMEOUT
+	start, limit := time.Now(), time.Duration(size+1)*protocol.Timeout
+	job := s.jobs.add(shuffle.Name, election.ID, limit)
+	protocol.Prompted = func(node string) {
+		s.jobs.update(job, func(j *api.Job) { j.Node = node })
 	}
+	s.background(job, protocol, protocol.Finished, limit, func(ok bool) error {
+		if complaints, _ := election.Complaints(); len(complaints) > 0 {
+			return ERR_COMPLAINT
+		} else if !ok {
+			return ERR_NOT_ENOUGH_MIXES
+		}
+		return election.Store(timing("shuffle", start))
+	})
+	return &api.ShuffleReply{Job: job}, nil
 }
 
 // Decrypt message handler. Initiate decryption protocol.
@@ -283,31 +450,128 @@ func (s *Service) Decrypt(req *api.Decrypt) (*api.DecryptReply, error) {
 		return nil, err
 	}
 
-	if election.Stage >= chains.DECRYPTED {
+	if election.Stage == chains.VOID {
+		return nil, ERR_VOID
+	} else if election.Stage >= chains.DECRYPTED {
 		return nil, ERR_ALREADY_DECRYPTED
 	} else if election.Stage < chains.SHUFFLED {
 		return nil, ERR_NOT_SHUFFLED
 	}
 
-	tree := election.Roster.GenerateNaryTreeWithRoot(1, s.ServerIdentity())
+	secret, found := s.secrets.get(election)
+	if !found {
+		return nil, ERR_NO_SECRET
+	}
+
+	size := len(election.Roster.List)
+	tree := election.Roster.GenerateNaryTreeWithRoot(size-1, s.ServerIdentity())
 	instance, _ := s.CreateProtocol(decrypt.Name, tree)
 	protocol := instance.(*decrypt.Protocol)
-	protocol.Secret = s.secrets[skipchain.SkipBlockID(election.ID).Short()]
+	protocol.Secret = secret
 	protocol.Election = election
+	protocol.Threshold = election.RequiredPartials()
 
-	config, _ := network.Marshal(&synchronizer{election.ID})
+	config, _ := network.Marshal(&synchronizer{ID: election.ID})
 	protocol.SetConfig(&onet.GenericConfig{Data: config})
 
-	if err = protocol.Start(); err != nil {
+	box, err := election.Box()
+	if err != nil {
 		return nil, err
 	}
 
-	select {
-	case <-protocol.Finished:
-		return &api.DecryptReply{}, nil
-	case <-time.After(5 * time.Second):
-		return nil, ERR_PROTOCOL_TIMEOUT
+	start, limit := time.Now(), timeout(len(box.Ballots), 1)
+	job := s.jobs.add(decrypt.Name, election.ID, limit)
+	s.background(job, protocol, protocol.Finished, limit, func(bool) error {
+		return election.Store(timing("decrypt", start))
+	})
+	return &api.DecryptReply{Job: job}, nil
+}
+
+// Reshare message handler. Move the shared secret of a running election to a
+// subset of its roster, keeping the election key. Retired nodes are left out.
+func (s *Service) Reshare(req *api.Reshare) (*api.ReshareReply, error) {
+	election, err := s.vet(req.Token, req.ID, true)
+	if err != nil {
+		return nil, err
 	}
+
+	threshold, err := reshareable(election, req.Roster, int(req.Threshold))
+	if err != nil {
+		return nil, err
+	}
+
+	secret, found := s.secrets.get(election)
+	if !found {
+		return nil, ERR_NO_SECRET
+	} else if !subset(s.node, req.Roster) {
+		return nil, ERR_INVALID_ROSTER
+	}
+
+	size := len(req.Roster.List)
+	tree := req.Roster.GenerateNaryTreeWithRoot(size, s.ServerIdentity())
+	instance, _ := s.CreateProtocol(dkg.NameReshare, tree)
+	protocol := instance.(*dkg.SetupDKG)
+	protocol.Secret = secret
+	protocol.NewRoster = req.Roster
+	protocol.Threshold = uint32(threshold)
+	protocol.Endorse = s.endorse(election, req.Roster, threshold, protocol.Private())
+
+	config, _ := network.Marshal(&synchronizer{
+		ID:        election.ID,
+		Roster:    req.Roster,
+		Threshold: uint32(threshold),
+	})
+	protocol.SetConfig(&onet.GenericConfig{Data: config})
+
+	start, limit := time.Now(), timeout(0, size)
+	job := s.jobs.add(dkg.NameReshare, election.ID, limit)
+	s.background(job, protocol, protocol.Done, limit, func(bool) error {
+		secret, err := protocol.SharedSecret()
+		if err != nil {
+			return err
+		}
+
+		change := chains.NewRosterChange(req.Roster, secret)
+		for _, e := range protocol.Endorsements {
+			if err := change.Endorse(election.ID, e.Public, e.Signature); err != nil {
+				log.Lvl2(s.ServerIdentity(), "dropping invalid endorsement", err)
+			}
+		}
+		if err := change.Verify(election); err != nil {
+			return err
+		}
+
+		if err := election.Store(change); err != nil {
+			return err
+		}
+		return election.Store(timing("reshare", start))
+	})
+	return &api.ReshareReply{Job: job}, nil
+}
+
+// GetJob message handler. Report the progress of a background protocol.
+func (s *Service) GetJob(req *api.GetJob) (*api.GetJobReply, error) {
+	if _, err := s.vet(req.Token, nil, true); err != nil {
+		return nil, err
+	}
+
+	job, found := s.jobs.get(req.ID)
+	if !found {
+		return nil, ERR_UNKNOWN_JOB
+	} else if job.Kind == dkg.Name {
+		return &api.GetJobReply{Job: job}, nil
+	}
+
+	election, err := s.vet(req.Token, job.Election, true)
+	if err != nil {
+		return nil, err
+	}
+	mixes, partials, err := election.Progress()
+	if err != nil {
+		return nil, err
+	}
+	job.Mixes, job.Partials = uint32(mixes), uint32(partials)
+	return &api.GetJobReply{Job: job}, nil
 }
 
 // Reconstruct message handler. Fully decrypt partials using Lagrange interpolation.
@@ -317,29 +581,116 @@ func (s *Service) Reconstruct(req *api.Reconstruct) (*api.ReconstructReply, erro
 		return nil, err
 	}
 
-	if election.Stage < chains.DECRYPTED {
+	if election.Stage == chains.VOID {
+		return nil, ERR_VOID
+	} else if election.Stage < chains.DECRYPTED {
 		return nil, ERR_NOT_DECRYPTED
 	}
 
-	partials, err := election.Partials()
+	points, rejected, err := reconstruct(election)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]string, len(rejected))
+	for i, partial := range rejected {
+		nodes[i] = partial.Node
+	}
+	return &api.ReconstructReply{Points: points, Rejected: nodes}, nil
+}
+
+// Tally message handler. Count the plaintexts and store the result.
+func (s *Service) Tally(req *api.Tally) (*api.TallyReply, error) {
+	election, err := s.vet(req.Token, req.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if election.Stage == chains.VOID {
+		return nil, ERR_VOID
+	} else if election.Stage >= chains.FINISHED {
+		return nil, ERR_ALREADY_TALLIED
+	} else if election.Stage < chains.DECRYPTED {
+		return nil, ERR_NOT_DECRYPTED
+	}
+
+	start := time.Now()
+	points, _, err := reconstruct(election)
 	if err != nil {
 		return nil, err
 	}
 
-	points := make([]kyber.Point, 0)
+	mixes, err := election.Mixes()
+	if err != nil {
+		return nil, err
+	}
 
-	n := len(election.Roster.List)
-	for i := 0; i < len(partials[0].Points); i++ {
-		shares := make([]*share.PubShare, n)
-		for j, partial := range partials {
-			shares[j] = &share.PubShare{I: j, V: partial.Points[i]}
+	results, err := tally.Contests(election, mixes[len(mixes)-1], points)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if err = election.Store(result); err != nil {
+			return nil, err
 		}
+	}
+	if err = election.Store(timing("tally", start)); err != nil {
+		return nil, err
+	}
+
+	return &api.TallyReply{Results: results}, nil
+}
+
+// GetResult message handler. Vet the stored tally result.
+func (s *Service) GetResult(req *api.GetResult) (*api.GetResultReply, error) {
+	election, err := s.vet(req.Token, req.ID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if election.Stage == chains.VOID {
+		return nil, ERR_VOID
+	} else if election.Stage < chains.FINISHED {
+		return nil, ERR_NOT_TALLIED
+	}
+
+	results, err := election.Results()
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.GetResultReply{Results: results}, nil
+}
+
+// GetStats message handler. Vet the size and the timings of an election.
+func (s *Service) GetStats(req *api.GetStats) (*api.GetStatsReply, error) {
+	election, err := s.vet(req.Token, req.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := election.Stats()
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.GetStatsReply{Stats: stats}, nil
+}
+
+// Export message handler. Bundle all artefacts of an election.
+func (s *Service) Export(req *api.Export) (*api.ExportReply, error) {
+	election, err := s.vet(req.Token, req.ID, false)
+	if err != nil {
+		return nil, err
+	}
 
-		message, _ := share.RecoverCommit(crypto.Suite, shares, n, n)
-		points = append(points, message)
+	bundle, err := export.Build(election)
+	if err != nil {
+		return nil, err
 	}
 
-	return &api.ReconstructReply{Points: points}, nil
+	return &api.ExportReply{Files: bundle.Files}, nil
 }
 
 // NewProtocol hooks non-root nodes into created protocols.
@@ -347,7 +698,8 @@ func (s *Service) NewProtocol(node *onet.TreeNodeInstance, conf *onet.GenericCon
 	onet.ProtocolInstance, error) {
 
 	_, blob, _ := network.Unmarshal(conf.Data, crypto.Suite)
-	id := blob.(*synchronizer).ID
+	synced := blob.(*synchronizer)
+	id := synced.ID
 
 	switch node.ProtocolName() {
 	case dkg.Name:
@@ -355,10 +707,36 @@ func (s *Service) NewProtocol(node *onet.TreeNodeInstance, conf *onet.GenericCon
 		protocol := instance.(*dkg.SetupDKG)
 		go func() {
 			<-protocol.Done
-			secret, _ := protocol.SharedSecret()
-			s.secrets[id.Short()] = secret
+			secret, err := protocol.SharedSecret()
+			if err != nil {
+				log.Error(s.ServerIdentity(), "failed to generate shared secret", err)
+				return
+			}
+			s.secrets.add(id, secret)
+			s.pools.fill(id, secret.X, int(synced.Capacity))
 		}()
 		return protocol, nil
+	case dkg.NameReshare:
+		election, err := chains.FetchElection(s.node, id)
+		if err != nil {
+			return nil, err
+		}
+		threshold, err := reshareable(election, synced.Roster, int(synced.Threshold))
+		if err != nil {
+			return nil, err
+		} else if !subset(node.Roster(), synced.Roster) {
+			return nil, ERR_INVALID_ROSTER
+		}
+		secret, found := s.secrets.get(election)
+		if !found {
+			return nil, ERR_NO_SECRET
+		}
+
+		instance, _ := dkg.NewSetupDKG(node)
+		protocol := instance.(*dkg.SetupDKG)
+		protocol.Secret = secret
+		protocol.Endorse = s.endorse(election, synced.Roster, threshold, protocol.Private())
+		return protocol, nil
 	case shuffle.Name:
 		election, err := chains.FetchElection(s.node, id)
 		if err != nil {
@@ -368,8 +746,9 @@ func (s *Service) NewProtocol(node *onet.TreeNodeInstance, conf *onet.GenericCon
 		instance, _ := shuffle.New(node)
 		protocol := instance.(*shuffle.Protocol)
 		protocol.Election = election
+		protocol.Pool = s.pools.take(id)
 
-		config, _ := network.Marshal(&synchronizer{election.ID})
+		config, _ := network.Marshal(&synchronizer{ID: election.ID})
 		protocol.SetConfig(&onet.GenericConfig{Data: config})
 
 		return protocol, nil
@@ -379,12 +758,18 @@ func (s *Service) NewProtocol(node *onet.TreeNodeInstance, conf *onet.GenericCon
 			return nil, err
 		}
 
+		secret, found := s.secrets.get(election)
+		if !found {
+			return nil, ERR_NO_SECRET
+		}
+
 		instance, _ := decrypt.New(node)
 		protocol := instance.(*decrypt.Protocol)
-		protocol.Secret = s.secrets[id.Short()]
+		protocol.Secret = secret
 		protocol.Election = election
+		protocol.Threshold = election.RequiredPartials()
 
-		config, _ := network.Marshal(&synchronizer{election.ID})
+		config, _ := network.Marshal(&synchronizer{ID: election.ID})
 		protocol.SetConfig(&onet.GenericConfig{Data: config})
 
 		return protocol, nil
@@ -393,12 +778,138 @@ func (s *Service) NewProtocol(node *onet.TreeNodeInstance, conf *onet.GenericCon
 	}
 }
 
+// reconstruct recovers the plaintexts from the partials of an election after
+// checking them against the public shares derived from the DKG commitments.
+func reconstruct(election *chains.Election) ([]kyber.Point, []*chains.Partial, error) {
+	mixes, err := election.Mixes()
+	if err != nil {
+		return nil, nil, err
+	}
+	partials, err := election.Partials()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n, mix := len(election.Roster.List), mixes[len(mixes)-1]
+	return decrypt.Reconstruct(election.Poly(), mix, partials, election.RequiredPartials(), n)
+}
+
+// reshareable checks that the secret of a running election may be moved to a
+// subset of its roster and returns the threshold of the new shares. The roster
+// can only shrink: conodes outside of it do not hold the election skipchain
+// and could neither fetch the election nor store its mixes and partials.
+func reshareable(election *chains.Election, roster *onet.Roster, threshold int) (int, error) {
+	if election.Stage == chains.VOID {
+		return 0, ERR_VOID
+	} else if election.Stage >= chains.SHUFFLED {
+		return 0, ERR_ALREADY_CLOSED
+	} else if !subset(roster, election.Roster) {
+		return 0, ERR_INVALID_ROSTER
+	}
+
+	size := len(roster.List)
+	if threshold == 0 {
+		threshold = dkg.Threshold(size)
+	} else if !dkg.ValidThreshold(threshold, size) {
+		return 0, ERR_INVALID_THRESHOLD
+	}
+	if m := int(election.MinMixes); m != 0 && (m < threshold || m > size) {
+		return 0, ERR_INVALID_MIXES
+	}
+	return threshold, nil
+}
+
+// endorse returns the hook with which a node checks its reshared secret
+// against the election, stages it and signs the resulting roster change. The
+// staged secret is only used once the change is on the skipchain.
+func (s *Service) endorse(election *chains.Election, roster *onet.Roster, threshold int,
+	private kyber.Scalar) func(*dkg.SharedSecret) ([]byte, error) {
+
+	return func(secret *dkg.SharedSecret) ([]byte, error) {
+		change := chains.NewRosterChange(roster, secret)
+		if int(change.Threshold) != threshold {
+			return nil, ERR_INVALID_THRESHOLD
+		} else if err := election.Reshared(change).VerifyTranscript(); err != nil {
+			return nil, err
+		}
+		s.secrets.add(election.ID, secret)
+		return change.Sign(election.ID, private)
+	}
+}
+
+// background starts a protocol and waits for it to finish in the background.
+// The job is concluded with the error returned by done or a timeout.
+func (s *Service) background(job string, protocol onet.ProtocolInstance, finished chan bool,
+	limit time.Duration, done func(bool) error) {
+
+	go func() {
+		if err := protocol.Start(); err != nil {
+			s.jobs.finish(job, err)
+			return
+		}
+
+		select {
+		case ok := <-finished:
+			s.jobs.finish(job, done(ok))
+		case <-time.After(limit):
+			s.jobs.finish(job, ERR_PROTOCOL_TIMEOUT)
+		}
+	}()
+}
+
+// timing measures the duration of a phase that began at start.
+func timing(phase string, start time.Time) *chains.Timing {
+	return &chains.Timing{
+		Name:     phase,
+		Start:    start.UnixNano(),
+		Duration: time.Since(start).Nanoseconds(),
+	}
+}
+
+// weigh checks that the weights of an election, if any, are positive and
+// aligned with the voter roll, and that no weight class is small enough to
+// single out its voters.
+func weigh(election *chains.Election) error {
+	if len(election.Weights) == 0 {
+		return nil
+	} else if len(election.Weights) != len(election.Users) {
+		return ERR_INVALID_WEIGHTS
+	}
+
+	for _, weight := range election.Weights {
+		if weight == 0 {
+			return ERR_INVALID_WEIGHTS
+		}
+	}
+	if !election.Anonymous() {
+		return ERR_SMALL_CLASS
+	}
+	return nil
+}
+
+// subset checks that the nodes of a roster are distinct and belong to
+// another roster.
+func subset(roster, of *onet.Roster) bool {
+	if roster == nil || len(roster.List) == 0 {
+		return false
+	}
+
+	seen := make(map[network.ServerIdentityID]bool)
+	for _, node := range roster.List {
+		if index, _ := of.Search(node.ID); index < 0 || seen[node.ID] {
+			return false
+		}
+		seen[node.ID] = true
+	}
+	return true
+}
+
 // vet checks the user stamp and fetches the election corresponding to the
 // given id while making sure the user is either a voter or the creator.
 func (s *Service) vet(token string, id skipchain.SkipBlockID, admin bool) (
 	*chains.Election, error) {
 
-	stamp, found := s.state.log[token]
+	stamp, found := s.state.get(token)
 	if !found {
 		return nil, ERR_NOT_LOGGED_IN
 	} else if admin && !stamp.admin {
@@ -427,14 +938,23 @@ func (s *Service) vet(token string, id skipchain.SkipBlockID, admin bool) (
 func new(context *onet.Context) (onet.Service, error) {
 	service := &Service{
 		ServiceProcessor: onet.NewServiceProcessor(context),
-		secrets:          make(map[string]*dkg.SharedSecret),
-		state:            &state{make(map[string]*stamp)},
+		secrets:          &secrets{log: make(map[string][]*dkg.SharedSecret)},
+		state:            &state{log: make(map[string]*stamp)},
+		jobs:             &jobs{log: make(map[string]*api.Job)},
+		pools:            &pools{log: make(map[string]*crypto.Pool)},
 		pin:              nonce(6),
 	}
 
+	service.validators = []chains.Validator{
+		chains.ValidPoints, chains.Unique, chains.Limit(maxBallots), chains.Proven,
+	}
+
 	service.RegisterHandlers(service.Ping, service.Link, service.Open, service.Login,
 		service.Cast, service.GetBox, service.GetMixes, service.Shuffle,
 		service.GetPartials, service.Decrypt, service.Reconstruct,
+		service.GetDiscarded, service.Tally, service.GetResult,
+		service.GetTurnout, service.GetStats, service.GetJob,
+		service.Export, service.Reshare,
 	)
 
 	service.state.schedule(3 * time.Minute)
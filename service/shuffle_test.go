@@ -10,6 +10,7 @@ import (
 	"github.com/qantik/nevv/api"
 	"github.com/qantik/nevv/chains"
 	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
 )
 
 func TestShuffle_UserNotLoggedIn(t *testing.T) {
@@ -64,6 +65,60 @@ func TestShuffle_UserNotCreator(t *testing.T) {
 	assert.Equal(t, ERR_NOT_CREATOR, err)
 }
 
+func TestShuffle_BelowQuorum(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0, 10, 11, 12},
+		Stage:   chains.RUNNING,
+		Quorum:  50,
+	}
+	_ = election.GenChain(3)
+
+	_, err := s.Shuffle(&api.Shuffle{Token: "0", ID: election.ID})
+	assert.Equal(t, ERR_QUORUM, err)
+
+	e, _ := chains.FetchElection(roster, election.ID)
+	assert.Equal(t, chains.VOID, int(e.Stage))
+
+	_, err = s.Shuffle(&api.Shuffle{Token: "0", ID: election.ID})
+	assert.Equal(t, ERR_VOID, err)
+}
+
+func TestShuffle_SmallClass(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		Weights: []uint32{1, 1, 1, 2, 2, 2, 2, 2, 2, 2},
+		Stage:   chains.RUNNING,
+	}
+	_ = election.GenChain(0)
+
+	for _, user := range []uint32{0, 1, 2, 9} {
+		alpha, beta := crypto.Encrypt(election.Key, []byte{byte(user)})
+		ballot := &chains.Ballot{User: user, Alpha: alpha, Beta: beta, Weight: election.Weight(user)}
+		_ = election.Store(ballot)
+	}
+
+	_, err := s.Shuffle(&api.Shuffle{Token: "0", ID: election.ID})
+	assert.Equal(t, ERR_SMALL_CLASS, err)
+}
+
 func TestShuffle_ElectionClosed(t *testing.T) {
 	local := onet.NewLocalTest(crypto.Suite)
 	defer local.CloseAll()
@@ -78,7 +133,7 @@ func TestShuffle_ElectionClosed(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.SHUFFLED,
 	}
-	_ = election.GenChain(3)
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
 
 	_, err := s.Shuffle(&api.Shuffle{Token: "0", ID: election.ID})
 	assert.Equal(t, ERR_ALREADY_SHUFFLED, err)
@@ -89,7 +144,7 @@ func TestShuffle_ElectionClosed(t *testing.T) {
 		Users:   []uint32{0},
 		Stage:   chains.DECRYPTED,
 	}
-	_ = election.GenChain(3)
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
 
 	_, err = s.Shuffle(&api.Shuffle{Token: "0", ID: election.ID})
 	assert.Equal(t, ERR_ALREADY_SHUFFLED, err)
@@ -113,4 +168,8 @@ func TestShuffle_Full(t *testing.T) {
 
 	r, _ := s.Shuffle(&api.Shuffle{Token: "0", ID: election.ID})
 	assert.NotNil(t, r)
+
+	job := await(s, r.Job)
+	assert.Equal(t, uint32(api.JOB_DONE), job.Status)
+	assert.NotEqual(t, "", job.Node)
 }
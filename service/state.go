@@ -2,6 +2,7 @@ package service
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -21,6 +22,8 @@ type stamp struct {
 
 // state is a wrapper around the log map.
 type state struct {
+	sync.Mutex
+
 	// log is map from nonce to user stamp.
 	log map[string]*stamp
 }
@@ -34,6 +37,7 @@ func (s *state) schedule(interval time.Duration) chan bool {
 		for {
 			select {
 			case <-ticker.C:
+				s.Lock()
 				for nonce, stamp := range s.log {
 					if stamp.time == 5 {
 						delete(s.log, nonce)
@@ -41,6 +45,7 @@ func (s *state) schedule(interval time.Duration) chan bool {
 						stamp.time++
 					}
 				}
+				s.Unlock()
 			case <-stop:
 				ticker.Stop()
 				return
@@ -53,11 +58,27 @@ func (s *state) schedule(interval time.Duration) chan bool {
 
 // register a new user in the log and return 32 character nonce as a token.
 func (s *state) register(user uint32, admin bool) string {
+	s.Lock()
+	defer s.Unlock()
+
 	token := nonce(32)
 	s.log[token] = &stamp{user, admin, 0}
 	return token
 }
 
+// get returns a copy of the stamp of a token.
+func (s *state) get(token string) (*stamp, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	stamp, found := s.log[token]
+	if !found {
+		return nil, false
+	}
+	copy := *stamp
+	return &copy, true
+}
+
 // nonce returns a random string for a given length n.
 func nonce(n int) string {
 	const chars = "0123456789abcdefghijklmnopqrstuvwxyz"
@@ -14,7 +14,7 @@ func TestNonce(t *testing.T) {
 }
 
 func TestSchedule(t *testing.T) {
-	s := state{make(map[string]*stamp)}
+	s := state{log: make(map[string]*stamp)}
 	s.log["u"] = &stamp{0, false, 4}
 
 	stop := s.schedule(time.Second)
@@ -26,7 +26,7 @@ func TestSchedule(t *testing.T) {
 }
 
 func TestRegister(t *testing.T) {
-	s := state{make(map[string]*stamp)}
+	s := state{log: make(map[string]*stamp)}
 	t1 := s.register(123, true)
 	t2 := s.register(456, false)
 
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dedis/onet"
+
+	"github.com/qantik/nevv/api"
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestGetStats_UserNotAdmin(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: false}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.RUNNING,
+	}
+	_ = election.GenChain(3)
+
+	_, err := s.GetStats(&api.GetStats{Token: "0", ID: election.ID})
+	assert.Equal(t, ERR_NOT_ADMIN, err)
+}
+
+func TestGetStats_Full(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.SHUFFLED,
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	r, _ := s.GetStats(&api.GetStats{Token: "0", ID: election.ID})
+	assert.Equal(t, uint32(3), r.Stats.Ballots)
+	assert.Equal(t, uint32(0), r.Stats.Revotes)
+	assert.Equal(t, 3, len(r.Stats.Mixes))
+	assert.Equal(t, 0, len(r.Stats.Partials))
+}
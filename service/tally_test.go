@@ -0,0 +1,149 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dedis/onet"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/api"
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestTally_ElectionNotDecrypted(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.SHUFFLED,
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	_, err := s.Tally(&api.Tally{Token: "0", ID: election.ID})
+	assert.Equal(t, ERR_NOT_DECRYPTED, err)
+}
+
+func TestTally_ElectionAlreadyTallied(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.FINISHED,
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	_, err := s.Tally(&api.Tally{Token: "0", ID: election.ID})
+	assert.Equal(t, ERR_ALREADY_TALLIED, err)
+}
+
+func TestTally_Full(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.DECRYPTED,
+		Schema:  &chains.Schema{Candidates: []string{"a", "b", "c", "d"}},
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	r, _ := s.Tally(&api.Tally{Token: "0", ID: election.ID})
+	assert.Equal(t, []uint32{0}, r.Results[0].Winners)
+	assert.Equal(t, []float64{1, 1, 1, 0}, r.Results[0].Rounds[0].Counts)
+
+	e, _ := chains.FetchElection(roster, election.ID)
+	assert.Equal(t, chains.FINISHED, int(e.Stage))
+}
+
+func TestTally_Contests(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.DECRYPTED,
+		Contests: []*chains.Contest{
+			&chains.Contest{Schema: &chains.Schema{Candidates: []string{"a", "b", "c"}}},
+			&chains.Contest{Schema: &chains.Schema{Candidates: []string{"x", "y"}}},
+		},
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	r, _ := s.Tally(&api.Tally{Token: "0", ID: election.ID})
+	assert.Equal(t, 2, len(r.Results))
+	assert.Equal(t, []float64{1, 1, 1}, r.Results[0].Rounds[0].Counts)
+	assert.Equal(t, uint32(1), r.Results[1].Contest)
+	assert.Equal(t, []float64{0, 0}, r.Results[1].Rounds[0].Counts)
+
+	e, _ := chains.FetchElection(roster, election.ID)
+	assert.Equal(t, chains.FINISHED, int(e.Stage))
+}
+
+func TestGetResult_ElectionNotTallied(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: false}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.DECRYPTED,
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	_, err := s.GetResult(&api.GetResult{Token: "0", ID: election.ID})
+	assert.Equal(t, ERR_NOT_TALLIED, err)
+}
+
+func TestGetResult_Full(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: false}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0},
+		Stage:   chains.FINISHED,
+	}
+	_ = election.GenChain(3, testutil.Keys(local, nodes)...)
+
+	r, _ := s.GetResult(&api.GetResult{Token: "0", ID: election.ID})
+	assert.Equal(t, 1, len(r.Results))
+}
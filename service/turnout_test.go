@@ -0,0 +1,112 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dedis/onet"
+
+	"github.com/qantik/nevv/api"
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+)
+
+func TestGetTurnout_UserNotLoggedIn(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, _, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+
+	_, err := s.GetTurnout(&api.GetTurnout{Token: "0", ID: []byte{}})
+	assert.Equal(t, ERR_NOT_LOGGED_IN, err)
+}
+
+func TestGetTurnout_UserNotAdmin(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["1"] = &stamp{user: 1, admin: false}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0, 1, 5, 6},
+		Stage:   chains.RUNNING,
+		Quorum:  60,
+	}
+	_ = election.GenChain(3)
+
+	r, _ := s.GetTurnout(&api.GetTurnout{Token: "1", ID: election.ID})
+	assert.Equal(t, uint32(2), r.Voted)
+	assert.Equal(t, uint32(4), r.Roll)
+	assert.Equal(t, uint32(60), r.Quorum)
+	assert.Nil(t, r.Voters)
+}
+
+func TestGetTurnout_UserNotPart(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["2"] = &stamp{user: 2, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0, 1},
+		Stage:   chains.RUNNING,
+	}
+	_ = election.GenChain(3)
+
+	_, err := s.GetTurnout(&api.GetTurnout{Token: "2", ID: election.ID})
+	assert.Equal(t, ERR_NOT_PART, err)
+}
+
+func TestGetTurnout_CreatorNotOnRoll(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["9"] = &stamp{user: 9, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 9,
+		Users:   []uint32{0, 1, 5, 6},
+		Stage:   chains.RUNNING,
+	}
+	_ = election.GenChain(3)
+
+	r, err := s.GetTurnout(&api.GetTurnout{Token: "9", ID: election.ID})
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), r.Voted)
+	assert.Equal(t, []uint32{0, 1}, r.Voters)
+}
+
+func TestGetTurnout_Full(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+	s := local.GetServices(nodes, serviceID)[0].(*Service)
+	s.state.log["0"] = &stamp{user: 0, admin: true}
+
+	election := &chains.Election{
+		Roster:  roster,
+		Creator: 0,
+		Users:   []uint32{0, 1, 5, 6},
+		Stage:   chains.VOID,
+	}
+	_ = election.GenChain(3)
+
+	r, _ := s.GetTurnout(&api.GetTurnout{Token: "0", ID: election.ID})
+	assert.Equal(t, uint32(2), r.Voted)
+	assert.Equal(t, []uint32{0, 1}, r.Voters)
+	assert.True(t, r.Void)
+}
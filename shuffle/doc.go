@@ -1,19 +1,24 @@
 /*
 Package shuffle implements the Neff shuffle protocol.
 
-Each participating node creates a verifiable shuffle with a corresponding proof
-of the last last block in election skipchain. This block is either a box of
-encrypted ballot (in case of the root node) or a mix of the previous node. Every
-newly created shuffle is appended to the chain before the next node is prompted
-to create its shuffle. The leaf node notifies the root upon storing its shuffle,
-which terminates the protocol. The individual mixes are not verified here but
-only in a later stage of the election.
+The root orchestrates the shuffle over a star tree. It first verifies the box
+of encrypted ballots and stores its own shuffle with a corresponding proof on
+the election skipchain. It then prompts each other node in turn, which verifies
+the last mix and replies with its own signed shuffle of it. The root is the
+only writer: it stores a mix only if it is signed by the prompted node, so that
+nodes which cannot be reached or do not reply within the timeout are skipped
+and their late mixes are discarded. A node that rejects the box or the previous
+mix replies with a signed complaint instead, which aborts the protocol.
 
 Schema:
 
-        [Prompt]            [Prompt]            [Prompt]         [Terminate]
-  Root ------------> Node1 ------------> Node2 --> ... --> Leaf ------------> Root
+        [Prompt]                 [Prompt]
+  Root ----------> Node1   Root ----------> Node2   ...
+       <----------              <----------
+         [Reply]                  [Reply]
 
-The protocol can only be started by the election's creator and is non-repeatable.
+The protocol succeeds if at least the required number of mixes of the election
+has been stored. It can only be started by the election's creator and is
+non-repeatable.
 */
 package shuffle
@@ -2,10 +2,12 @@ package shuffle
 
 import (
 	"github.com/dedis/onet"
+
+	"github.com/qantik/nevv/chains"
 )
 
-// Prompt is sent from node to node prompting the receiver to perform
-// their respective shuffle (re-encryption) of the ballots.
+// Prompt is sent from the root to one node at a time prompting the receiver
+// to verify the last mix and perform its shuffle (re-encryption).
 type Prompt struct{}
 
 // MessagePrompt is a wrapper around Prompt.
@@ -14,12 +16,15 @@ type MessagePrompt struct {
 	Prompt
 }
 
-// Terminate is sent by the leaf node to the root node upon completion of
-// the last shuffle, which terminates the protocol.
-type Terminate struct{}
+// Reply is sent back to the root carrying either the signed mix of a node or
+// its signed complaint against the previous one. Only the root stores them.
+type Reply struct {
+	Mix       *chains.Mix       // Mix is the shuffle of the node.
+	Complaint *chains.Complaint // Complaint against the previous mix.
+}
 
-// MessageTerminate is a wrapper around Terminate.
-type MessageTerminate struct {
+// MessageReply is a wrapper around Reply.
+type MessageReply struct {
 	*onet.TreeNode
-	Terminate
+	Reply
 }
@@ -2,10 +2,10 @@ package shuffle
 
 import (
 	"errors"
+	"time"
 
-	"github.com/dedis/kyber/proof"
-	"github.com/dedis/kyber/shuffle"
 	"github.com/dedis/onet"
+	"github.com/dedis/onet/log"
 	"github.com/dedis/onet/network"
 
 	"github.com/qantik/nevv/chains"
@@ -15,74 +15,238 @@ import (
 // Name is the protocol identifier string.
 const Name = "shuffle"
 
+// Timeout is the default time the root waits for a node to store its mix.
+const Timeout = 5 * time.Second
+
+var (
+	ERR_MISSING_MIX   = errors.New("Mix of the previous node is missing")
+	ERR_EMPTY_REPLY   = errors.New("Reply carries neither a mix nor a complaint")
+	ERR_FOREIGN_REPLY = errors.New("Reply is not signed by the prompted node")
+)
+
 // Protocol is the core structure of the protocol.
 type Protocol struct {
 	*onet.TreeNodeInstance
 
 	Election *chains.Election // Election to be shuffled.
+	Pool     *crypto.Pool     // Pool of precomputed re-encryption factors, if any.
+	Timeout  time.Duration    // Timeout after which a node is skipped.
 	Finished chan bool        // Flag to signal protocol termination.
+
+	// Prompted is called at the root with the name of each node before it
+	// shuffles, if set.
+	Prompted func(node string)
+
+	replies chan MessageReply // replies forwards the replies to the root.
 }
 
 func init() {
-	network.RegisterMessages(Prompt{}, Terminate{})
+	network.RegisterMessages(Prompt{}, Reply{})
 	onet.GlobalProtocolRegister(Name, New)
 }
 
 // New initializes the protocol object and registers all the handlers.
 func New(node *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
-	protocol := &Protocol{TreeNodeInstance: node, Finished: make(chan bool, 1)}
-	protocol.RegisterHandlers(protocol.HandlePrompt, protocol.HandleTerminate)
+	protocol := &Protocol{
+		TreeNodeInstance: node,
+		Timeout:          Timeout,
+		Finished:         make(chan bool, 1),
+		replies:          make(chan MessageReply, len(node.Roster().List)),
+	}
+	protocol.RegisterHandlers(protocol.HandlePrompt, protocol.HandleReply)
 	return protocol, nil
 }
 
-// Start is called on the root node prompting it to send itself a Prompt message.
+// Start stores the mix of the root and prompts the other nodes one after the
+// other in the background.
 func (p *Protocol) Start() error {
-	return p.HandlePrompt(MessagePrompt{p.TreeNode(), Prompt{}})
+	p.prompted(p.TreeNode())
+	reply, err := p.mix()
+	if err != nil {
+		return err
+	} else if err = p.store(reply); err != nil {
+		return err
+	} else if reply.Complaint != nil {
+		p.Finished <- false
+		return nil
+	}
+
+	go p.orchestrate()
+	return nil
 }
 
-// HandlePrompt retrieves, shuffles and stores the mix back on the skipchain.
+// HandlePrompt sends the mix of a node, or its complaint, to the root.
 func (p *Protocol) HandlePrompt(prompt MessagePrompt) error {
-	var ballots []*chains.Ballot
-	if p.IsRoot() {
-		box, err := p.Election.Box()
-		if err != nil {
-			return err
+	reply, err := p.mix()
+	if err != nil {
+		return err
+	}
+	return p.SendToParent(reply)
+}
+
+// HandleReply forwards a reply to the orchestrating root.
+func (p *Protocol) HandleReply(reply MessageReply) error {
+	p.replies <- reply
+	return nil
+}
+
+// orchestrate prompts each child in turn, waits for its reply and stores it.
+// Unreachable and unresponsive nodes as well as invalid replies are skipped,
+// so that the root is the only writer and late mixes are never stored.
+// Finished is false if a complaint has been raised or too few mixes have been
+// stored.
+func (p *Protocol) orchestrate() {
+	for _, child := range p.Children() {
+		p.prompted(child)
+		if err := p.SendTo(child, &Prompt{}); err != nil {
+			log.Lvl2(p.Name(), "failed to prompt", child.Name(), err)
+			continue
 		}
-		ballots = box.Ballots
-	} else {
-		mixes, err := p.Election.Mixes()
-		if err != nil {
-			return err
+
+		reply, ok := p.await(child)
+		if !ok {
+			log.Lvl2(p.Name(), "skipping unresponsive", child.Name())
+			continue
+		} else if err := p.check(child, reply); err != nil {
+			log.Lvl2(p.Name(), "skipping invalid reply of", child.Name(), err)
+			continue
+		} else if err := p.store(reply); err != nil {
+			log.Error(p.Name(), "failed to store reply of", child.Name(), err)
+			p.Finished <- false
+			return
+		} else if reply.Complaint != nil {
+			p.Finished <- false
+			return
 		}
-		ballots = mixes[len(mixes)-1].Ballots
 	}
 
-	if len(ballots) < 2 {
-		return errors.New("Not enough (> 2) ballots to shuffle")
+	mixes, err := p.Election.Mixes()
+	p.Finished <- err == nil && len(mixes) >= p.Election.RequiredMixes()
+}
 
+// prompted reports the node about to shuffle.
+func (p *Protocol) prompted(node *onet.TreeNode) {
+	if p.Prompted != nil {
+		p.Prompted(node.Name())
 	}
+}
 
-	a, b := chains.Split(ballots)
-	g, d, prover := shuffle.Shuffle(crypto.Suite, nil, p.Election.Key, a, b, crypto.Stream)
+// await waits for the reply of a node until the timeout expires. Late replies
+// of skipped nodes are discarded.
+func (p *Protocol) await(node *onet.TreeNode) (*Reply, bool) {
+	timeout := time.After(p.Timeout)
+	for {
+		select {
+		case reply := <-p.replies:
+			if reply.TreeNode.ID == node.ID {
+				return &reply.Reply, true
+			}
+		case <-timeout:
+			return nil, false
+		}
+	}
+}
 
-	proof, err := proof.HashProve(crypto.Suite, "", prover)
+// check makes sure that a reply carries a mix or a complaint signed by the
+// node that was prompted.
+func (p *Protocol) check(node *onet.TreeNode, reply *Reply) error {
+	public := node.ServerIdentity.Public
+	if reply.Complaint != nil {
+		if reply.Complaint.Public == nil || !reply.Complaint.Public.Equal(public) {
+			return ERR_FOREIGN_REPLY
+		}
+		return reply.Complaint.Verify(p.Election.ID)
+	} else if reply.Mix == nil {
+		return ERR_EMPTY_REPLY
+	} else if reply.Mix.Signer == nil || !reply.Mix.Signer.Equal(public) {
+		return ERR_FOREIGN_REPLY
+	}
+	return reply.Mix.Verify(p.Election.ID)
+}
+
+// store appends the mix or the complaint of a reply to the skipchain.
+func (p *Protocol) store(reply *Reply) error {
+	if reply.Complaint != nil {
+		return p.Election.Store(reply.Complaint)
+	}
+	return p.Election.Store(reply.Mix)
+}
+
+// mix verifies the last mix, or the box in case of the root, and returns a
+// signed shuffle of it. A node that rejects its input returns a signed
+// complaint instead.
+func (p *Protocol) mix() (*Reply, error) {
+	start := time.Now()
+
+	box, err := p.Election.Box()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	mixes, err := p.Election.Mixes()
+	if err != nil {
+		return nil, err
 	}
 
-	mix := &chains.Mix{Ballots: chains.Combine(g, d), Proof: proof, Node: p.Name()}
-	if err := p.Election.Store(mix); err != nil {
-		return err
+	ballots, err := p.verify(box, mixes)
+	if err != nil && p.IsRoot() {
+		return p.complain(-1, err)
+	} else if err != nil {
+		return p.complain(len(mixes)-1, err)
 	}
 
-	if p.IsLeaf() {
-		return p.SendTo(p.Root(), &Terminate{})
+	if len(ballots) < 2 {
+		return nil, errors.New("Not enough (> 2) ballots to shuffle")
 	}
-	return p.SendToChildren(&Prompt{})
+
+	prover, err := p.Election.Prover()
+	if err != nil {
+		return nil, err
+	}
+	mix, err := chains.Shuffle(prover, p.Election.Key, ballots, p.Pool)
+	if err != nil {
+		return nil, err
+	}
+
+	mix.Node = p.Name()
+	mix.Start, mix.Duration = start.UnixNano(), time.Since(start).Nanoseconds()
+	if err := mix.Sign(p.Election.ID, p.Private()); err != nil {
+		return nil, err
+	}
+	return &Reply{Mix: mix}, nil
 }
 
-// HandleTerminate concludes the protocol.
-func (p *Protocol) HandleTerminate(terminate MessageTerminate) error {
-	p.Finished <- true
-	return nil
+// verify returns the ballots to be shuffled after checking the box for the
+// root and the proofs of the last mix for every other node.
+func (p *Protocol) verify(box *chains.Box, mixes []*chains.Mix) ([]*chains.Ballot, error) {
+	if p.IsRoot() {
+		return box.Ballots, chains.VerifyBox(p.Election, box.Ballots)
+	} else if len(mixes) == 0 {
+		return nil, ERR_MISSING_MIX
+	}
+
+	ballots := box.Ballots
+	if len(mixes) > 1 {
+		ballots = mixes[len(mixes)-2].Ballots
+	}
+	prover, err := p.Election.Prover()
+	if err != nil {
+		return nil, err
+	}
+	last := mixes[len(mixes)-1]
+	return last.Ballots, chains.VerifyShuffle(prover, p.Election.Key, ballots, last)
+}
+
+// complain returns a signed complaint against a mix, or the box if the index
+// is negative.
+func (p *Protocol) complain(mix int, reason error) (*Reply, error) {
+	complaint := &chains.Complaint{
+		Node:   p.Name(),
+		Public: p.Public(),
+		Mix:    mix,
+		Reason: reason.Error(),
+	}
+	if err := complaint.Sign(p.Election.ID, p.Private()); err != nil {
+		return nil, err
+	}
+	return &Reply{Complaint: complaint}, nil
 }
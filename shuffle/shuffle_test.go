@@ -7,6 +7,8 @@ import (
 
 	"github.com/dedis/onet"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/qantik/nevv/chains"
 	"github.com/qantik/nevv/crypto"
 )
@@ -16,6 +18,7 @@ var serviceID onet.ServiceID
 type service struct {
 	*onet.ServiceProcessor
 	election *chains.Election
+	offline  bool
 }
 
 func init() {
@@ -28,6 +31,10 @@ func init() {
 func (s *service) NewProtocol(n *onet.TreeNodeInstance, c *onet.GenericConfig) (
 	onet.ProtocolInstance, error) {
 
+	if s.offline {
+		return nil, errors.New("Offline")
+	}
+
 	switch n.ProtocolName() {
 	case Name:
 		instance, _ := New(n)
@@ -49,7 +56,7 @@ func run(t *testing.T, n int) {
 	local := onet.NewLocalTest(crypto.Suite)
 	defer local.CloseAll()
 
-	nodes, roster, tree := local.GenBigTree(n, n, 1, true)
+	nodes, roster, _ := local.GenBigTree(n, n, 1, true)
 
 	election := &chains.Election{Roster: roster, Stage: chains.RUNNING}
 	_ = election.GenChain(n)
@@ -59,13 +66,15 @@ func run(t *testing.T, n int) {
 		services[i].(*service).election = election
 	}
 
+	tree := roster.GenerateNaryTreeWithRoot(n-1, nodes[0].ServerIdentity)
 	instance, _ := services[0].(*service).CreateProtocol(Name, tree)
 	protocol := instance.(*Protocol)
 	protocol.Election = election
 	protocol.Start()
 
 	select {
-	case <-protocol.Finished:
+	case ok := <-protocol.Finished:
+		assert.True(t, ok)
 		// box, _ := election.Box()
 		// mixes, _ := election.Mixes()
 
@@ -76,3 +85,153 @@ func run(t *testing.T, n int) {
 		t.Fatal("Protocol timeout")
 	}
 }
+
+func TestProtocol_Complaint(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.RUNNING}
+	_ = election.GenChain(3)
+	_ = election.Store(&chains.Ballot{
+		User: 1000, Alpha: crypto.Suite.Point().Null(), Beta: crypto.Suite.Point().Null(),
+	})
+
+	services := local.GetServices(nodes, serviceID)
+	for i := range services {
+		services[i].(*service).election = election
+	}
+
+	tree := roster.GenerateNaryTreeWithRoot(2, nodes[0].ServerIdentity)
+	instance, _ := services[0].(*service).CreateProtocol(Name, tree)
+	protocol := instance.(*Protocol)
+	protocol.Election = election
+	protocol.Start()
+
+	select {
+	case ok := <-protocol.Finished:
+		assert.False(t, ok)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Protocol timeout")
+	}
+
+	complaints, _ := election.Complaints()
+	assert.Equal(t, 1, len(complaints))
+	assert.Equal(t, -1, complaints[0].Mix)
+	assert.Nil(t, complaints[0].Verify(election.ID))
+
+	mixes, _ := election.Mixes()
+	assert.Equal(t, 0, len(mixes))
+
+	e, _ := chains.FetchElection(roster, election.ID)
+	assert.Equal(t, chains.CORRUPT, int(e.Stage))
+}
+
+func TestProtocol_Offline(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(4, 4, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.RUNNING}
+	_ = election.GenChain(3)
+
+	services := local.GetServices(nodes, serviceID)
+	for i := range services {
+		services[i].(*service).election = election
+	}
+	services[2].(*service).offline = true
+
+	tree := roster.GenerateNaryTreeWithRoot(3, nodes[0].ServerIdentity)
+	instance, _ := services[0].(*service).CreateProtocol(Name, tree)
+	protocol := instance.(*Protocol)
+	protocol.Election = election
+	protocol.Timeout = 500 * time.Millisecond
+	protocol.Start()
+
+	select {
+	case ok := <-protocol.Finished:
+		assert.True(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Protocol timeout")
+	}
+
+	mixes, _ := election.Mixes()
+	assert.Equal(t, 3, len(mixes))
+
+	e, _ := chains.FetchElection(roster, election.ID)
+	assert.Equal(t, chains.SHUFFLED, int(e.Stage))
+}
+
+func TestProtocol_Pool(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.RUNNING}
+	_ = election.GenChain(3)
+
+	services := local.GetServices(nodes, serviceID)
+	for i := range services {
+		services[i].(*service).election = election
+	}
+
+	tree := roster.GenerateNaryTreeWithRoot(2, nodes[0].ServerIdentity)
+	instance, _ := services[0].(*service).CreateProtocol(Name, tree)
+	protocol := instance.(*Protocol)
+	protocol.Election = election
+	protocol.Pool = crypto.NewPool(election.Key)
+	protocol.Pool.Fill(5)
+	protocol.Start()
+
+	select {
+	case ok := <-protocol.Finished:
+		assert.True(t, ok)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Protocol timeout")
+	}
+	assert.Equal(t, 2, protocol.Pool.Size())
+
+	e, _ := chains.FetchElection(roster, election.ID)
+	assert.Equal(t, chains.SHUFFLED, int(e.Stage))
+}
+
+func TestProtocol_Late(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.RUNNING}
+	_ = election.GenChain(3)
+
+	services := local.GetServices(nodes, serviceID)
+	for i := range services {
+		services[i].(*service).election = election
+	}
+
+	tree := roster.GenerateNaryTreeWithRoot(2, nodes[0].ServerIdentity)
+	instance, _ := services[0].(*service).CreateProtocol(Name, tree)
+	protocol := instance.(*Protocol)
+	protocol.Election = election
+	protocol.Timeout = time.Nanosecond
+	protocol.Start()
+
+	select {
+	case ok := <-protocol.Finished:
+		assert.False(t, ok)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Protocol timeout")
+	}
+
+	time.Sleep(time.Second)
+	mixes, _ := election.Mixes()
+	assert.Equal(t, 1, len(mixes))
+
+	reply := &Reply{Mix: mixes[0]}
+	assert.Equal(t, ERR_FOREIGN_REPLY, protocol.check(protocol.Children()[0], reply))
+	assert.Equal(t, ERR_EMPTY_REPLY, protocol.check(protocol.Children()[0], &Reply{}))
+	assert.Nil(t, protocol.check(protocol.TreeNode(), reply))
+}
@@ -1,16 +1,31 @@
 package shuffle
 
-// Simulate performs and offline version of the shuffle protocol.
-// func Simulate(n int, key abstract.Point, ballots []*chains.Ballot) []*chains.Mix {
-// 	mixes := make([]*chains.Mix, n)
-// 	for i := range mixes {
-// 		x, y := chains.Split(ballots)
-// 		v, w, _, prover := crypto.Shuffle(key, x, y)
+import (
+	"fmt"
+	"time"
 
-// 		proof, _ := proof.HashProve(crypto.Suite, "", crypto.Stream, prover)
+	"github.com/dedis/kyber"
 
-// 		ballots = chains.Combine(v, w)
-// 		mixes[i] = &chains.Mix{Ballots: ballots, Proof: proof, Node: string(i)}
-// 	}
-// 	return mixes
-// }
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+)
+
+// Simulate performs an offline version of the shuffle protocol in which n
+// nodes shuffle the ballots one after the other.
+func Simulate(n int, prover crypto.ShuffleProver, key kyber.Point, ballots []*chains.Ballot) (
+	[]*chains.Mix, error) {
+
+	mixes := make([]*chains.Mix, n)
+	for i := range mixes {
+		start := time.Now()
+		mix, err := chains.Shuffle(prover, key, ballots, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		mix.Node = fmt.Sprintf("node-%d", i)
+		mix.Start, mix.Duration = start.UnixNano(), time.Since(start).Nanoseconds()
+		mixes[i], ballots = mix, mix.Ballots
+	}
+	return mixes, nil
+}
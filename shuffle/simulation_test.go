@@ -1,9 +1,26 @@
 package shuffle
 
-// func TestSimulate(t *testing.T) {
-// 	key := crypto.Random()
-// 	ballot := &chains.Ballot{Alpha: crypto.Random(), Beta: crypto.Random()}
+import (
+	"testing"
 
-// 	mixes := Simulate(3, key, []*chains.Ballot{ballot, ballot})
-// 	assert.Equal(t, 3, len(mixes))
-// }
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+)
+
+func TestSimulate(t *testing.T) {
+	_, key := crypto.RandomKeyPair()
+	ballots := make([]*chains.Ballot, 3)
+	for i := range ballots {
+		alpha, beta := crypto.Encrypt(key, []byte{byte(i)})
+		ballots[i] = &chains.Ballot{User: uint32(i), Alpha: alpha, Beta: beta}
+	}
+
+	mixes, _ := Simulate(3, crypto.Neff{}, key, ballots)
+	assert.Equal(t, 3, len(mixes))
+	for _, mix := range mixes {
+		assert.Nil(t, chains.VerifyShuffle(crypto.Neff{}, key, ballots, mix))
+		ballots = mix.Ballots
+	}
+}
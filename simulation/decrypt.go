@@ -0,0 +1,97 @@
+package main
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/log"
+	"github.com/dedis/onet/simul/monitor"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/decrypt"
+	"github.com/qantik/nevv/dkg"
+)
+
+// DecryptSimulation measures the decryption protocol over a shuffled box.
+type DecryptSimulation struct {
+	onet.SimulationBFTree
+
+	Ballots int // Ballots is the size of the box.
+}
+
+func init() {
+	onet.SimulationRegister("Decrypt", NewDecryptSimulation)
+}
+
+// NewDecryptSimulation reads the simulation parameters from a toml config.
+func NewDecryptSimulation(config string) (onet.Simulation, error) {
+	simulation := &DecryptSimulation{}
+	if _, err := toml.Decode(config, simulation); err != nil {
+		return nil, err
+	}
+	return simulation, nil
+}
+
+// Setup creates the roster and the tree of the simulation.
+func (s *DecryptSimulation) Setup(dir string, hosts []string) (*onet.SimulationConfig, error) {
+	return setup(&s.SimulationBFTree, hosts)
+}
+
+// Node initializes every node before the simulation is run.
+func (s *DecryptSimulation) Node(config *onet.SimulationConfig) error {
+	return node(&s.SimulationBFTree, config)
+}
+
+// Run decrypts a new shuffled election in every round. The key shares of the
+// in-memory DKG of the election are dealt to the nodes, and neither the
+// election nor its shuffle are part of the measured round.
+func (s *DecryptSimulation) Run(config *onet.SimulationConfig) error {
+	log.Lvl2("Decrypting", s.Ballots, "ballots with", len(config.Roster.List), "nodes")
+	for round := 0; round < s.Rounds; round++ {
+		election := &chains.Election{Roster: config.Roster, Stage: chains.RUNNING}
+		dkgs := election.GenChain(s.Ballots)
+		if err := runShuffle(config, election, s.Ballots); err != nil {
+			return err
+		}
+
+		secrets := make([]*dkg.SharedSecret, len(dkgs))
+		for i := range dkgs {
+			secrets[i], _ = dkg.NewSharedSecret(dkgs[i])
+		}
+
+		measure := monitor.NewTimeMeasure("round")
+		synced := &synchronizer{ID: election.ID, Secrets: secrets}
+		if err := runDecrypt(config, election, synced); err != nil {
+			return err
+		}
+		measure.Record()
+	}
+	return nil
+}
+
+// runDecrypt runs the decryption protocol on an election from the root and
+// waits for the threshold of partials.
+func runDecrypt(config *onet.SimulationConfig, election *chains.Election,
+	synced *synchronizer) error {
+
+	secret, err := config.GetService(Name).(*service).secret(config.Roster, synced)
+	if err != nil {
+		return err
+	}
+
+	instance, err := create(config, decrypt.Name)
+	if err != nil {
+		return err
+	}
+	protocol := instance.(*decrypt.Protocol)
+	protocol.Secret = secret
+	protocol.Election = election
+	if err := configure(protocol, synced); err != nil {
+		return err
+	}
+
+	if err := protocol.Start(); err != nil {
+		return err
+	}
+	<-protocol.Finished
+	return nil
+}
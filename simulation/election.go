@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/binary"
+
+	"github.com/BurntSushi/toml"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/log"
+	"github.com/dedis/onet/simul/monitor"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/decrypt"
+	"github.com/qantik/nevv/dkg"
+)
+
+// ElectionSimulation measures a complete election from the DKG to the
+// reconstruction of the plaintexts.
+type ElectionSimulation struct {
+	onet.SimulationBFTree
+
+	Ballots int    // Ballots is the number of cast ballots.
+	Mixnet  string // Mixnet is the shuffle proof system, Neff by default.
+}
+
+func init() {
+	onet.SimulationRegister("Election", NewElectionSimulation)
+}
+
+// NewElectionSimulation reads the simulation parameters from a toml config.
+func NewElectionSimulation(config string) (onet.Simulation, error) {
+	simulation := &ElectionSimulation{}
+	if _, err := toml.Decode(config, simulation); err != nil {
+		return nil, err
+	}
+	return simulation, nil
+}
+
+// Setup creates the roster and the tree of the simulation.
+func (s *ElectionSimulation) Setup(dir string, hosts []string) (*onet.SimulationConfig, error) {
+	return setup(&s.SimulationBFTree, hosts)
+}
+
+// Node initializes every node before the simulation is run.
+func (s *ElectionSimulation) Node(config *onet.SimulationConfig) error {
+	return node(&s.SimulationBFTree, config)
+}
+
+// Run holds a new election in every round and measures each of its phases.
+func (s *ElectionSimulation) Run(config *onet.SimulationConfig) error {
+	size := len(config.Roster.List)
+	log.Lvl2("Running an election of", s.Ballots, "ballots with", size, "nodes")
+	for round := 0; round < s.Rounds; round++ {
+		measure := monitor.NewTimeMeasure("round")
+
+		phase := monitor.NewTimeMeasure("dkg")
+		genesis, err := chains.New(config.Roster, nil)
+		if err != nil {
+			return err
+		}
+		secret, err := runDKG(config, &synchronizer{ID: genesis.Hash})
+		if err != nil {
+			return err
+		}
+
+		election := &chains.Election{
+			ID:     genesis.Hash,
+			Roster: config.Roster,
+			Key:    secret.X,
+			Mixnet: s.Mixnet,
+		}
+		if err := election.Store(election); err != nil {
+			return err
+		}
+		phase.Record()
+
+		phase = monitor.NewTimeMeasure("cast")
+		for i := 0; i < s.Ballots; i++ {
+			message := make([]byte, 4)
+			binary.BigEndian.PutUint32(message, uint32(i))
+			alpha, beta := crypto.Encrypt(secret.X, message)
+			ballot := &chains.Ballot{User: uint32(i), Alpha: alpha, Beta: beta}
+			if err := election.Store(ballot); err != nil {
+				return err
+			}
+		}
+		phase.Record()
+
+		phase = monitor.NewTimeMeasure("shuffle")
+		if err := runShuffle(config, election, s.Ballots); err != nil {
+			return err
+		}
+		phase.Record()
+
+		phase = monitor.NewTimeMeasure("decrypt")
+		if err := runDecrypt(config, election, &synchronizer{ID: election.ID}); err != nil {
+			return err
+		}
+		phase.Record()
+
+		phase = monitor.NewTimeMeasure("reconstruct")
+		mixes, err := election.Mixes()
+		if err != nil {
+			return err
+		}
+		partials, err := election.Partials()
+		if err != nil {
+			return err
+		}
+		mix := mixes[len(mixes)-1]
+		_, _, err = decrypt.Reconstruct(secret.Poly(), mix, partials, dkg.Threshold(size), size)
+		if err != nil {
+			return err
+		}
+		phase.Record()
+
+		measure.Record()
+	}
+	return nil
+}
+
+// runDKG runs the DKG protocol from the root and keeps the share of the root.
+func runDKG(config *onet.SimulationConfig, synced *synchronizer) (*dkg.SharedSecret, error) {
+	instance, err := create(config, dkg.Name)
+	if err != nil {
+		return nil, err
+	}
+	protocol := instance.(*dkg.SetupDKG)
+	if err := configure(protocol, synced); err != nil {
+		return nil, err
+	}
+
+	if err := protocol.Start(); err != nil {
+		return nil, err
+	}
+	<-protocol.Done
+
+	secret, err := protocol.SharedSecret()
+	if err != nil {
+		return nil, err
+	}
+	config.GetService(Name).(*service).store(synced.ID, secret)
+	return secret, nil
+}
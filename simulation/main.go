@@ -0,0 +1,14 @@
+// Command simulation runs the onet simulations of the shuffle and decryption
+// protocols and of complete elections, configured by the toml files of this
+// directory, e.g.
+//
+//	go run . shuffle.toml
+package main
+
+import (
+	"github.com/dedis/onet/simul"
+)
+
+func main() {
+	simul.Start()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/dedis/cothority/skipchain"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/network"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/decrypt"
+	"github.com/qantik/nevv/dkg"
+	"github.com/qantik/nevv/shuffle"
+)
+
+// Name is the identifier of the simulation service.
+const Name = "nevv-simulation"
+
+var (
+	ERR_NO_SECRET        = errors.New("Election has no shared secret on this node")
+	ERR_PROTOCOL_UNKNOWN = errors.New("Protocol unknown")
+)
+
+// service hooks the simulated nodes into the protocols like the nevv service
+// does, without requiring logged in users.
+type service struct {
+	*onet.ServiceProcessor
+	sync.Mutex
+
+	secrets map[string]*dkg.SharedSecret // secrets is a map of DKG products.
+}
+
+// synchronizer is broadcasted to all nodes before every protocol.
+type synchronizer struct {
+	ID skipchain.SkipBlockID
+
+	// Secrets are the shares of an in-memory DKG in roster order. They are
+	// dealt with the configuration to simulate the decryption on its own.
+	Secrets []*dkg.SharedSecret
+}
+
+func init() {
+	network.RegisterMessage(synchronizer{})
+	onet.RegisterNewService(Name, func(context *onet.Context) (onet.Service, error) {
+		return &service{
+			ServiceProcessor: onet.NewServiceProcessor(context),
+			secrets:          make(map[string]*dkg.SharedSecret),
+		}, nil
+	})
+}
+
+// NewProtocol hooks non-root nodes into created protocols.
+func (s *service) NewProtocol(node *onet.TreeNodeInstance, conf *onet.GenericConfig) (
+	onet.ProtocolInstance, error) {
+
+	_, blob, err := network.Unmarshal(conf.Data, crypto.Suite)
+	if err != nil {
+		return nil, err
+	}
+	synced := blob.(*synchronizer)
+
+	switch node.ProtocolName() {
+	case dkg.Name:
+		instance, _ := dkg.NewSetupDKG(node)
+		protocol := instance.(*dkg.SetupDKG)
+		go func() {
+			<-protocol.Done
+			secret, _ := protocol.SharedSecret()
+			s.store(synced.ID, secret)
+		}()
+		return protocol, nil
+	case shuffle.Name:
+		election, err := chains.FetchElection(s.node(), synced.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		instance, _ := shuffle.New(node)
+		protocol := instance.(*shuffle.Protocol)
+		protocol.Election = election
+		protocol.SetConfig(conf)
+		return protocol, nil
+	case decrypt.Name:
+		election, err := chains.FetchElection(s.node(), synced.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		secret, err := s.secret(node.Roster(), synced)
+		if err != nil {
+			return nil, err
+		}
+
+		instance, _ := decrypt.New(node)
+		protocol := instance.(*decrypt.Protocol)
+		protocol.Secret = secret
+		protocol.Election = election
+		protocol.SetConfig(conf)
+		return protocol, nil
+	default:
+		return nil, ERR_PROTOCOL_UNKNOWN
+	}
+}
+
+// store keeps the DKG product of an election.
+func (s *service) store(id skipchain.SkipBlockID, secret *dkg.SharedSecret) {
+	s.Lock()
+	defer s.Unlock()
+	s.secrets[id.Short()] = secret
+}
+
+// secret returns the share of this node, either dealt with the configuration
+// or produced by a DKG protocol.
+func (s *service) secret(roster *onet.Roster, synced *synchronizer) (*dkg.SharedSecret, error) {
+	if len(synced.Secrets) > 0 {
+		index, _ := roster.Search(s.ServerIdentity().ID)
+		if index < 0 || index >= len(synced.Secrets) {
+			return nil, ERR_NO_SECRET
+		}
+		return synced.Secrets[index], nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	secret, found := s.secrets[synced.ID.Short()]
+	if !found {
+		return nil, ERR_NO_SECRET
+	}
+	return secret, nil
+}
+
+// node returns a unitary roster of this node to fetch skipchains.
+func (s *service) node() *onet.Roster {
+	return onet.NewRoster([]*network.ServerIdentity{s.ServerIdentity()})
+}
+
+// configure attaches a synchronizer to a protocol created at the root.
+func configure(protocol onet.ProtocolInstance, synced *synchronizer) error {
+	data, err := network.Marshal(synced)
+	if err != nil {
+		return err
+	}
+	return protocol.SetConfig(&onet.GenericConfig{Data: data})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/BurntSushi/toml"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/log"
+	"github.com/dedis/onet/simul/monitor"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/shuffle"
+)
+
+var ERR_SHUFFLE = errors.New("Shuffle did not store enough mixes")
+
+// ShuffleSimulation measures the shuffle protocol over a box of ballots.
+type ShuffleSimulation struct {
+	onet.SimulationBFTree
+
+	Ballots int    // Ballots is the size of the box.
+	Mixnet  string // Mixnet is the shuffle proof system, Neff by default.
+}
+
+func init() {
+	onet.SimulationRegister("Shuffle", NewShuffleSimulation)
+}
+
+// NewShuffleSimulation reads the simulation parameters from a toml config.
+func NewShuffleSimulation(config string) (onet.Simulation, error) {
+	simulation := &ShuffleSimulation{}
+	if _, err := toml.Decode(config, simulation); err != nil {
+		return nil, err
+	}
+	return simulation, nil
+}
+
+// Setup creates the roster and the tree of the simulation.
+func (s *ShuffleSimulation) Setup(dir string, hosts []string) (*onet.SimulationConfig, error) {
+	return setup(&s.SimulationBFTree, hosts)
+}
+
+// Node initializes every node before the simulation is run.
+func (s *ShuffleSimulation) Node(config *onet.SimulationConfig) error {
+	return node(&s.SimulationBFTree, config)
+}
+
+// Run shuffles a new election in every round. Generating the election is not
+// part of the measured round.
+func (s *ShuffleSimulation) Run(config *onet.SimulationConfig) error {
+	log.Lvl2("Shuffling", s.Ballots, "ballots with", len(config.Roster.List), "nodes")
+	for round := 0; round < s.Rounds; round++ {
+		election := &chains.Election{Roster: config.Roster, Stage: chains.RUNNING, Mixnet: s.Mixnet}
+		election.GenChain(s.Ballots)
+
+		measure := monitor.NewTimeMeasure("round")
+		if err := runShuffle(config, election, s.Ballots); err != nil {
+			return err
+		}
+		measure.Record()
+	}
+	return nil
+}
+
+// runShuffle runs the shuffle protocol on an election from the root.
+func runShuffle(config *onet.SimulationConfig, election *chains.Election, ballots int) error {
+	instance, err := create(config, shuffle.Name)
+	if err != nil {
+		return err
+	}
+	protocol := instance.(*shuffle.Protocol)
+	protocol.Election = election
+	protocol.Timeout = timeout(ballots)
+	if err := configure(protocol, &synchronizer{ID: election.ID}); err != nil {
+		return err
+	}
+
+	if err := protocol.Start(); err != nil {
+		return err
+	} else if !<-protocol.Finished {
+		return ERR_SHUFFLE
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"time"
+
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/log"
+
+	"github.com/qantik/nevv/shuffle"
+)
+
+// ballotTimeout is the additional time a node is given per ballot to shuffle.
+const ballotTimeout = 20 * time.Millisecond
+
+// setup creates the roster and the tree shared by all simulations.
+func setup(tree *onet.SimulationBFTree, hosts []string) (*onet.SimulationConfig, error) {
+	config := &onet.SimulationConfig{}
+	tree.CreateRoster(config, hosts, 2000)
+	if err := tree.CreateTree(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// node checks that a node is part of the roster before it is run.
+func node(tree *onet.SimulationBFTree, config *onet.SimulationConfig) error {
+	index, _ := config.Roster.Search(config.Server.ServerIdentity.ID)
+	if index < 0 {
+		log.Fatal("Node is not part of the roster")
+	}
+	return tree.Node(config)
+}
+
+// create instantiates a protocol at the root with all other nodes as its
+// children, as the nevv service does.
+func create(config *onet.SimulationConfig, name string) (onet.ProtocolInstance, error) {
+	size := len(config.Roster.List)
+	tree := config.Roster.GenerateNaryTreeWithRoot(size-1, config.Server.ServerIdentity)
+	return config.GetService(Name).(*service).CreateProtocol(name, tree)
+}
+
+// timeout is the time a node is given to shuffle a box.
+func timeout(ballots int) time.Duration {
+	return shuffle.Timeout + time.Duration(ballots)*ballotTimeout
+}
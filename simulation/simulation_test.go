@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dedis/onet/simul"
+)
+
+func TestSimulation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Simulations are not run in short mode")
+	}
+	simul.Start("shuffle.toml", "decrypt.toml", "election.toml")
+}
@@ -0,0 +1,189 @@
+// Package simulator runs whole elections in memory, from the DKG to the
+// reconstruction of the plaintexts, to measure the cost of each phase
+// without starting any conodes.
+package simulator
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/decrypt"
+	"github.com/qantik/nevv/dkg"
+	"github.com/qantik/nevv/shuffle"
+)
+
+var (
+	ERR_INVALID_CONFIG = errors.New("Simulation needs nodes, two ballots and a majority threshold")
+	ERR_INVALID_MIXES  = errors.New("Mixes do not verify")
+	ERR_MISMATCH       = errors.New("Reconstructed plaintexts do not match the ballots")
+)
+
+// Config holds the sizes of a simulated election.
+type Config struct {
+	Nodes   int    // Nodes is the size of the roster.
+	Ballots int    // Ballots is the number of cast ballots.
+	Mixes   int    // Mixes is the number of shuffles, 0 for one per node.
+	Mixnet  string // Mixnet is the shuffle proof system, Neff by default.
+
+	// Threshold is the number of partials needed to decrypt, 0 for the
+	// default DKG threshold.
+	Threshold int
+}
+
+// Phase is the time spent in one step of a simulation.
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Report holds the configuration and the phase timings of a simulation.
+type Report struct {
+	Config Config
+	Phases []*Phase
+}
+
+// Phases are the steps of a simulation in order.
+var Phases = []string{"dkg", "ballots", "shuffle", "verify", "decrypt", "reconstruct"}
+
+// Run simulates an election and checks that the reconstructed plaintexts
+// match the cast ballots.
+func Run(config Config) (*Report, error) {
+	if config.Threshold == 0 {
+		config.Threshold = dkg.Threshold(config.Nodes)
+	}
+	if config.Nodes < 1 || config.Ballots < 2 || !dkg.ValidThreshold(config.Threshold, config.Nodes) {
+		return nil, ERR_INVALID_CONFIG
+	}
+	if config.Mixes == 0 {
+		config.Mixes = config.Nodes
+	}
+	prover, err := crypto.Prover(config.Mixnet)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Config: config, Phases: make([]*Phase, 0)}
+	measure := func(name string, start time.Time) {
+		report.Phases = append(report.Phases, &Phase{name, time.Since(start)})
+	}
+
+	start := time.Now()
+	dkgs, err := dkg.Simulate(config.Nodes, config.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	secrets := make([]*dkg.SharedSecret, len(dkgs))
+	for i := range dkgs {
+		if secrets[i], err = dkg.NewSharedSecret(dkgs[i]); err != nil {
+			return nil, err
+		}
+	}
+	key := secrets[0].X
+	measure("dkg", start)
+
+	start = time.Now()
+	box := &chains.Box{Ballots: make([]*chains.Ballot, config.Ballots)}
+	for i := range box.Ballots {
+		alpha, beta := crypto.Encrypt(key, encode(i))
+		box.Ballots[i] = &chains.Ballot{User: uint32(i), Alpha: alpha, Beta: beta}
+	}
+	measure("ballots", start)
+
+	start = time.Now()
+	mixes, err := shuffle.Simulate(config.Mixes, prover, key, box.Ballots)
+	if err != nil {
+		return nil, err
+	}
+	measure("shuffle", start)
+
+	start = time.Now()
+	if !decrypt.Verify(prover, key, box, mixes) {
+		return nil, ERR_INVALID_MIXES
+	}
+	measure("verify", start)
+
+	start = time.Now()
+	mix := mixes[len(mixes)-1]
+	partials, err := decrypt.Simulate(secrets, mix)
+	if err != nil {
+		return nil, err
+	}
+	measure("decrypt", start)
+
+	start = time.Now()
+	points, _, err := decrypt.Reconstruct(secrets[0].Poly(), mix, partials, config.Threshold,
+		config.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	measure("reconstruct", start)
+
+	messages := make([]int, len(points))
+	for i, point := range points {
+		data, err := point.Data()
+		if err != nil || len(data) != 4 {
+			return nil, ERR_MISMATCH
+		}
+		messages[i] = int(binary.BigEndian.Uint32(data))
+	}
+	sort.Ints(messages)
+	for i, message := range messages {
+		if message != i {
+			return nil, ERR_MISMATCH
+		}
+	}
+	return report, nil
+}
+
+// Total returns the duration of the whole simulation.
+func (r *Report) Total() time.Duration {
+	var total time.Duration
+	for _, phase := range r.Phases {
+		total += phase.Duration
+	}
+	return total
+}
+
+// Table writes the reports as a table with one row per simulation and the
+// phase timings in milliseconds.
+func Table(w io.Writer, reports []*Report) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(table, "nodes\tballots\tmixes\tmixnet\t")
+	for _, phase := range Phases {
+		fmt.Fprintf(table, "%s\t", phase)
+	}
+	fmt.Fprintln(table, "total\t")
+
+	for _, report := range reports {
+		config := report.Config
+		mixnet := config.Mixnet
+		if mixnet == "" {
+			mixnet = crypto.NEFF
+		}
+		fmt.Fprintf(table, "%d\t%d\t%d\t%s\t", config.Nodes, config.Ballots, config.Mixes, mixnet)
+		for _, phase := range report.Phases {
+			fmt.Fprintf(table, "%s\t", milliseconds(phase.Duration))
+		}
+		fmt.Fprintf(table, "%s\t\n", milliseconds(report.Total()))
+	}
+	return table.Flush()
+}
+
+// encode maps a ballot index to the message it encrypts.
+func encode(i int) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, uint32(i))
+	return buf
+}
+
+// milliseconds formats a duration in milliseconds.
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,56 @@
+package simulator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/crypto"
+)
+
+func TestRun(t *testing.T) {
+	report, err := Run(Config{Nodes: 3, Ballots: 5})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, report.Config.Mixes)
+	assert.Equal(t, len(Phases), len(report.Phases))
+	for i, phase := range report.Phases {
+		assert.Equal(t, Phases[i], phase.Name)
+	}
+
+	report, err = Run(Config{Nodes: 4, Ballots: 3, Mixes: 2, Mixnet: crypto.WIKSTROM})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, report.Config.Mixes)
+	assert.Equal(t, 3, report.Config.Threshold)
+
+	report, err = Run(Config{Nodes: 5, Ballots: 3, Threshold: 5})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, report.Config.Threshold)
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	_, err := Run(Config{Nodes: 0, Ballots: 5})
+	assert.Equal(t, ERR_INVALID_CONFIG, err)
+
+	_, err = Run(Config{Nodes: 3, Ballots: 1})
+	assert.Equal(t, ERR_INVALID_CONFIG, err)
+
+	_, err = Run(Config{Nodes: 4, Ballots: 5, Threshold: 2})
+	assert.Equal(t, ERR_INVALID_CONFIG, err)
+
+	_, err = Run(Config{Nodes: 3, Ballots: 5, Mixnet: "bayer-groth"})
+	assert.Equal(t, crypto.ERR_UNKNOWN_PROVER, err)
+}
+
+func TestTable(t *testing.T) {
+	report, _ := Run(Config{Nodes: 3, Ballots: 2})
+
+	var buf bytes.Buffer
+	assert.Nil(t, Table(&buf, []*Report{report, report}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Contains(t, lines[0], "reconstruct")
+	assert.Contains(t, lines[1], crypto.NEFF)
+}
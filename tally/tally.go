@@ -0,0 +1,374 @@
+package tally
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/dedis/kyber"
+
+	"github.com/qantik/nevv/chains"
+)
+
+var (
+	ERR_NO_SCHEMA      = errors.New("Election has no ballot schema")
+	ERR_UNKNOWN_METHOD = errors.New("Counting method unknown")
+)
+
+// Vote is a decoded plaintext holding candidate indices in order of preference.
+type Vote []int
+
+// Method counts a list of valid votes with their weights according to a
+// schema. Implementations have to be deterministic, i.e. yield the same result
+// for the same input.
+type Method func(schema *chains.Schema, votes []Vote, weights []uint32) *chains.Result
+
+// methods maps the counting methods to their implementation.
+var methods = map[uint32]Method{
+	chains.PLURALITY: Plurality,
+	chains.APPROVAL:  Approval,
+	chains.IRV:       InstantRunoff,
+	chains.STV:       SingleTransferable,
+}
+
+// Register adds a counting method under a given identifier.
+func Register(id uint32, method Method) {
+	methods[id] = method
+}
+
+// Count decodes the plaintexts through the schema and runs its counting method.
+// The weights are aligned with the plaintexts, a missing or zero weight counts
+// as one. Plaintexts that cannot be decoded are counted as invalid.
+func Count(schema *chains.Schema, points []kyber.Point, weights []uint32) (
+	*chains.Result, error) {
+
+	if schema == nil {
+		return nil, ERR_NO_SCHEMA
+	}
+
+	method, found := methods[schema.Method]
+	if !found {
+		return nil, ERR_UNKNOWN_METHOD
+	}
+
+	votes, valid, invalid := make([]Vote, 0), make([]uint32, 0), 0
+	for i, point := range points {
+		if vote, ok := decode(schema, point); ok {
+			votes = append(votes, vote)
+			valid = append(valid, weight(weights, i))
+		} else {
+			invalid++
+		}
+	}
+
+	result := method(schema, votes, valid)
+	result.Invalid = uint32(invalid)
+	return result, nil
+}
+
+// Contests counts every contest of an election separately. The plaintexts
+// are aligned with the ballots of the final mix, which are segmented by contest.
+func Contests(election *chains.Election, mix *chains.Mix, points []kyber.Point) (
+	[]*chains.Result, error) {
+
+	results := make([]*chains.Result, 0)
+	for i, contest := range election.Races() {
+		segment, weights := make([]kyber.Point, 0), make([]uint32, 0)
+		for _, j := range mix.Segment(uint32(i)) {
+			segment = append(segment, points[j])
+			weights = append(weights, mix.Ballots[j].Weight)
+		}
+
+		result, err := Count(contest.Schema, segment, weights)
+		if err != nil {
+			return nil, err
+		}
+		result.Contest = uint32(i)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Equal checks if two results have the same winners, invalid ballots and
+// breakdown of rounds.
+func Equal(a, b *chains.Result) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if a.Contest != b.Contest || a.Invalid != b.Invalid || !equal(a.Winners, b.Winners) ||
+		len(a.Rounds) != len(b.Rounds) {
+		return false
+	}
+	for i := range a.Rounds {
+		r1, r2 := a.Rounds[i], b.Rounds[i]
+		if !equal(r1.Elected, r2.Elected) || !equal(r1.Eliminated, r2.Eliminated) {
+			return false
+		} else if len(r1.Counts) != len(r2.Counts) {
+			return false
+		}
+		for j := range r1.Counts {
+			if r1.Counts[j] != r2.Counts[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Plurality elects the candidates with the most first choices.
+func Plurality(schema *chains.Schema, votes []Vote, weights []uint32) *chains.Result {
+	tallies := zeros(len(schema.Candidates))
+	for i, vote := range votes {
+		tallies[vote[0]].Add(tallies[vote[0]], rat(weights, i))
+	}
+	return single(schema, tallies)
+}
+
+// Approval elects the candidates approved by the most voters.
+func Approval(schema *chains.Schema, votes []Vote, weights []uint32) *chains.Result {
+	tallies := zeros(len(schema.Candidates))
+	for i, vote := range votes {
+		for _, choice := range vote {
+			tallies[choice].Add(tallies[choice], rat(weights, i))
+		}
+	}
+	return single(schema, tallies)
+}
+
+// InstantRunoff eliminates the last ranked candidate in each round until a
+// candidate holds the majority of the votes that are not yet exhausted.
+func InstantRunoff(schema *chains.Schema, votes []Vote, weights []uint32) *chains.Result {
+	continuing := running(len(schema.Candidates))
+
+	result := &chains.Result{Winners: make([]uint32, 0)}
+	for {
+		tallies, total := zeros(len(schema.Candidates)), new(big.Rat)
+		for i, vote := range votes {
+			if c := first(vote, continuing); c >= 0 {
+				tallies[c].Add(tallies[c], rat(weights, i))
+				total.Add(total, rat(weights, i))
+			}
+		}
+
+		round := &chains.Round{Counts: floats(tallies)}
+		result.Rounds = append(result.Rounds, round)
+
+		order := rank(tallies, continuing)
+		if len(order) == 0 {
+			return result
+		}
+
+		half := new(big.Rat).Mul(total, big.NewRat(1, 2))
+		if len(order) == 1 || tallies[order[0]].Cmp(half) > 0 {
+			round.Elected = []uint32{uint32(order[0])}
+			result.Winners = round.Elected
+			return result
+		}
+
+		loser := order[len(order)-1]
+		continuing[loser] = false
+		round.Eliminated = []uint32{uint32(loser)}
+	}
+}
+
+// SingleTransferable fills the seats with the single transferable vote using
+// the exact Droop quota. Surpluses of elected candidates are transferred
+// fractionally to the next preferences of all their votes (Gregory method).
+func SingleTransferable(schema *chains.Schema, votes []Vote, weights []uint32) *chains.Result {
+	continuing := running(len(schema.Candidates))
+
+	values, total := make([]*big.Rat, len(votes)), new(big.Rat)
+	for i := range values {
+		values[i] = rat(weights, i)
+		total.Add(total, values[i])
+	}
+	quota := new(big.Rat).Quo(total, big.NewRat(int64(seats(schema)+1), 1))
+
+	result := &chains.Result{Winners: make([]uint32, 0)}
+	for len(result.Winners) < seats(schema) {
+		tallies, holders := zeros(len(schema.Candidates)), make([]int, len(votes))
+		for i, vote := range votes {
+			holders[i] = first(vote, continuing)
+			if holders[i] >= 0 {
+				tallies[holders[i]].Add(tallies[holders[i]], values[i])
+			}
+		}
+
+		round := &chains.Round{Counts: floats(tallies)}
+		result.Rounds = append(result.Rounds, round)
+
+		order := rank(tallies, continuing)
+		if len(order) == 0 {
+			break
+		}
+
+		if len(result.Winners)+len(order) <= seats(schema) {
+			round.Elected = uint32s(order)
+			result.Winners = append(result.Winners, round.Elected...)
+			break
+		}
+
+		for _, c := range order {
+			if tallies[c].Cmp(quota) <= 0 {
+				break
+			}
+
+			ratio := new(big.Rat).Sub(tallies[c], quota)
+			ratio.Quo(ratio, tallies[c])
+			for i, holder := range holders {
+				if holder == c {
+					values[i].Mul(values[i], ratio)
+				}
+			}
+			continuing[c] = false
+			round.Elected = append(round.Elected, uint32(c))
+		}
+
+		if len(round.Elected) == 0 {
+			loser := order[len(order)-1]
+			continuing[loser] = false
+			round.Eliminated = []uint32{uint32(loser)}
+		}
+		result.Winners = append(result.Winners, round.Elected...)
+	}
+	return result
+}
+
+// decode converts a plaintext into a vote. The plaintext is invalid if it
+// contains unknown or repeated candidates or does not suit the method.
+func decode(schema *chains.Schema, point kyber.Point) (Vote, bool) {
+	data, err := point.Data()
+	if err != nil {
+		return nil, false
+	}
+
+	seen := make(map[int]bool)
+	vote := make(Vote, len(data))
+	for i, b := range data {
+		c := int(b)
+		if c >= len(schema.Candidates) || seen[c] {
+			return nil, false
+		}
+		seen[c] = true
+		vote[i] = c
+	}
+
+	switch schema.Method {
+	case chains.PLURALITY:
+		return vote, len(vote) == 1
+	case chains.IRV, chains.STV:
+		return vote, len(vote) > 0
+	default:
+		return vote, true
+	}
+}
+
+// single creates the result of a single round method.
+func single(schema *chains.Schema, tallies []*big.Rat) *chains.Result {
+	order := rank(tallies, running(len(tallies)))
+	if len(order) > seats(schema) {
+		order = order[:seats(schema)]
+	}
+
+	winners := uint32s(order)
+	round := &chains.Round{Counts: floats(tallies), Elected: winners}
+	return &chains.Result{Winners: winners, Rounds: []*chains.Round{round}}
+}
+
+// rank returns the continuing candidates sorted by descending tally. Ties
+// are broken in favour of the candidate with the lower index.
+func rank(tallies []*big.Rat, continuing []bool) []int {
+	order := make([]int, 0)
+	for c := range tallies {
+		if continuing[c] {
+			order = append(order, c)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return tallies[order[i]].Cmp(tallies[order[j]]) > 0
+	})
+	return order
+}
+
+// first returns the most preferred continuing candidate of a vote or -1 if
+// the vote is exhausted.
+func first(vote Vote, continuing []bool) int {
+	for _, c := range vote {
+		if continuing[c] {
+			return c
+		}
+	}
+	return -1
+}
+
+// weight returns the i-th weight of a list, a missing or zero weight is one.
+func weight(weights []uint32, i int) uint32 {
+	if i >= len(weights) || weights[i] == 0 {
+		return 1
+	}
+	return weights[i]
+}
+
+// rat returns the i-th weight of a list as an exact number.
+func rat(weights []uint32, i int) *big.Rat {
+	return big.NewRat(int64(weight(weights, i)), 1)
+}
+
+// seats returns the number of winners, which is at least one.
+func seats(schema *chains.Schema) int {
+	if schema.Seats == 0 {
+		return 1
+	}
+	return int(schema.Seats)
+}
+
+// running creates a list of n continuing candidates.
+func running(n int) []bool {
+	continuing := make([]bool, n)
+	for i := range continuing {
+		continuing[i] = true
+	}
+	return continuing
+}
+
+// zeros creates a list of n empty tallies.
+func zeros(n int) []*big.Rat {
+	tallies := make([]*big.Rat, n)
+	for i := range tallies {
+		tallies[i] = new(big.Rat)
+	}
+	return tallies
+}
+
+// floats converts the exact tallies for the round breakdown.
+func floats(tallies []*big.Rat) []float64 {
+	counts := make([]float64, len(tallies))
+	for i, tally := range tallies {
+		counts[i], _ = tally.Float64()
+	}
+	return counts
+}
+
+// equal checks if two lists of candidates are identical.
+func equal(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// uint32s converts a list of candidate indices.
+func uint32s(candidates []int) []uint32 {
+	converted := make([]uint32, len(candidates))
+	for i, c := range candidates {
+		converted[i] = uint32(c)
+	}
+	return converted
+}
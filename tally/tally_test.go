@@ -0,0 +1,128 @@
+package tally
+
+import (
+	"testing"
+
+	"github.com/dedis/kyber"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+)
+
+// embed creates n plaintexts holding the given choices.
+func embed(n int, choices ...byte) []kyber.Point {
+	points := make([]kyber.Point, n)
+	for i := range points {
+		points[i] = crypto.Suite.Point().Embed(choices, crypto.Stream)
+	}
+	return points
+}
+
+func TestCount(t *testing.T) {
+	_, err := Count(nil, nil, nil)
+	assert.Equal(t, ERR_NO_SCHEMA, err)
+
+	_, err = Count(&chains.Schema{Method: 100}, nil, nil)
+	assert.Equal(t, ERR_UNKNOWN_METHOD, err)
+
+	schema := &chains.Schema{Candidates: []string{"a", "b"}, Method: chains.PLURALITY}
+	points := append(embed(1, 0), embed(1, 2)...)
+	points = append(points, embed(1, 0, 1)...)
+
+	result, _ := Count(schema, points, nil)
+	assert.Equal(t, uint32(2), result.Invalid)
+	assert.Equal(t, []float64{1, 0}, result.Rounds[0].Counts)
+}
+
+func TestPlurality(t *testing.T) {
+	schema := &chains.Schema{Candidates: []string{"a", "b", "c"}, Method: chains.PLURALITY}
+	points := append(embed(2, 0), embed(3, 1)...)
+	points = append(points, embed(2, 2)...)
+
+	result, _ := Count(schema, points, nil)
+	assert.Equal(t, []uint32{1}, result.Winners)
+	assert.Equal(t, []float64{2, 3, 2}, result.Rounds[0].Counts)
+}
+
+func TestApproval(t *testing.T) {
+	schema := &chains.Schema{Candidates: []string{"a", "b", "c"}, Method: chains.APPROVAL, Seats: 2}
+	points := append(embed(2, 0, 2), embed(3, 1)...)
+	points = append(points, embed(1)...)
+
+	result, _ := Count(schema, points, nil)
+	assert.Equal(t, []uint32{1, 0}, result.Winners)
+	assert.Equal(t, []float64{2, 3, 2}, result.Rounds[0].Counts)
+	assert.Equal(t, uint32(0), result.Invalid)
+}
+
+func TestInstantRunoff(t *testing.T) {
+	schema := &chains.Schema{Candidates: []string{"a", "b", "c"}, Method: chains.IRV}
+	points := append(embed(4, 0), embed(3, 1, 2)...)
+	points = append(points, embed(2, 2, 1)...)
+
+	result, _ := Count(schema, points, nil)
+	assert.Equal(t, []uint32{1}, result.Winners)
+	assert.Equal(t, 2, len(result.Rounds))
+	assert.Equal(t, []uint32{2}, result.Rounds[0].Eliminated)
+	assert.Equal(t, []float64{4, 5, 0}, result.Rounds[1].Counts)
+}
+
+func TestSingleTransferable(t *testing.T) {
+	schema := &chains.Schema{Candidates: []string{"a", "b", "c"}, Method: chains.STV, Seats: 2}
+	points := append(embed(5, 0, 2), embed(2, 1)...)
+	points = append(points, embed(2, 2)...)
+
+	result, _ := Count(schema, points, nil)
+	assert.Equal(t, []uint32{0, 2}, result.Winners)
+	assert.Equal(t, 2, len(result.Rounds))
+	assert.Equal(t, []float64{5, 2, 2}, result.Rounds[0].Counts)
+	assert.Equal(t, []float64{0, 2, 4}, result.Rounds[1].Counts)
+}
+
+func TestCount_Weights(t *testing.T) {
+	schema := &chains.Schema{Candidates: []string{"a", "b"}, Method: chains.PLURALITY}
+	points := append(embed(2, 0), embed(1, 1)...)
+	points = append(points, embed(1, 2)...)
+
+	result, _ := Count(schema, points, []uint32{1, 0, 5, 7})
+	assert.Equal(t, uint32(1), result.Invalid)
+	assert.Equal(t, []float64{2, 5}, result.Rounds[0].Counts)
+	assert.Equal(t, []uint32{1}, result.Winners)
+
+	schema = &chains.Schema{Candidates: []string{"a", "b", "c"}, Method: chains.IRV}
+	points = append(embed(1, 0, 1), embed(1, 1, 0)...)
+	points = append(points, embed(1, 2, 1)...)
+
+	result, _ = Count(schema, points, []uint32{3, 2, 2})
+	assert.Equal(t, []float64{3, 2, 2}, result.Rounds[0].Counts)
+	assert.Equal(t, []float64{3, 4, 0}, result.Rounds[1].Counts)
+	assert.Equal(t, []uint32{1}, result.Winners)
+}
+
+func TestDeterminism(t *testing.T) {
+	schema := &chains.Schema{Candidates: []string{"a", "b", "c"}, Method: chains.STV, Seats: 2}
+	points := append(embed(3, 0, 1, 2), embed(3, 1, 2, 0)...)
+	points = append(points, embed(3, 2, 0, 1)...)
+
+	r1, _ := Count(schema, points, nil)
+	r2, _ := Count(schema, points, nil)
+	assert.Equal(t, r1, r2)
+}
+
+func TestEqual(t *testing.T) {
+	schema := &chains.Schema{Candidates: []string{"a", "b"}, Method: chains.IRV}
+	r1, _ := Count(schema, append(embed(2, 0, 1), embed(1, 1)...), nil)
+	r2, _ := Count(schema, append(embed(2, 0, 1), embed(1, 1)...), nil)
+	r3, _ := Count(schema, append(embed(1, 0, 1), embed(2, 1)...), nil)
+
+	assert.True(t, Equal(r1, r2))
+	assert.False(t, Equal(r1, r3))
+	assert.False(t, Equal(r1, nil))
+	assert.True(t, Equal(nil, nil))
+
+	r2.Rounds[0].Eliminated = nil
+	r1.Rounds[0].Eliminated = []uint32{}
+	assert.True(t, Equal(r1, r2))
+}
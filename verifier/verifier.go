@@ -0,0 +1,222 @@
+// Verifier is a standalone universal verifier for nevv elections. It fetches
+// an election skipchain from the roster and checks the DKG transcript, the
+// ballot proofs, the number of mixes and their proofs and, if the transcript
+// is valid, the partial decryption proofs before it recomputes the
+// reconstruction and the tally. The outcome is printed as a JSON report and
+// the exit code is non-zero if any check fails.
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dedis/cothority/skipchain"
+	"github.com/dedis/kyber"
+	"github.com/dedis/onet"
+	"github.com/dedis/onet/app"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/decrypt"
+	"github.com/qantik/nevv/tally"
+)
+
+// Check is the outcome of a single verification step.
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the machine-readable result of the verification.
+type Report struct {
+	Election string   `json:"election"`
+	Stage    uint32   `json:"stage"`
+	Passed   bool     `json:"passed"`
+	Checks   []*Check `json:"checks"`
+}
+
+// add appends the outcome of a verification step to the report.
+func (r *Report) add(name string, err error) {
+	check := &Check{Name: name, Passed: err == nil}
+	if err != nil {
+		check.Error = err.Error()
+		r.Passed = false
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+func main() {
+	argRoster := flag.String("roster", "", "path to group toml file")
+	argID := flag.String("id", "", "hex encoded election skipchain ID")
+	flag.Parse()
+
+	roster, err := parseRoster(*argRoster)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	id, err := hex.DecodeString(*argID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	report, err := verify(roster, id)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+// verify runs all checks on the election skipchain with the given ID.
+func verify(roster *onet.Roster, id skipchain.SkipBlockID) (*Report, error) {
+	election, err := chains.FetchElection(roster, id)
+	if err != nil {
+		return nil, err
+	}
+
+	box, err := election.Box()
+	if err != nil {
+		return nil, err
+	}
+	mixes, err := election.Mixes()
+	if err != nil {
+		return nil, err
+	}
+	partials, err := election.Partials()
+	if err != nil {
+		return nil, err
+	}
+	results, err := election.Results()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Election: hex.EncodeToString(id), Stage: election.Stage, Passed: true}
+	if election.Stage == chains.CORRUPT {
+		report.add("stage", errors.New("election skipchain is corrupt"))
+	}
+
+	transcript := election.VerifyTranscript()
+	report.add("transcript", transcript)
+
+	for i, ballot := range box.Ballots {
+		report.add(fmt.Sprintf("ballot %d", i), verifyBallot(election, ballot))
+	}
+
+	prover, err := election.Prover()
+	report.add("mixnet", err)
+	if err != nil {
+		return report, nil
+	}
+
+	if len(mixes) < election.RequiredMixes() {
+		report.add("mixes", fmt.Errorf("%d of %d required mixes stored", len(mixes),
+			election.RequiredMixes()))
+	} else {
+		report.add("mixes", nil)
+	}
+
+	ballots := box.Ballots
+	for i, mix := range mixes {
+		err := chains.VerifyShuffle(prover, election.Key, ballots, mix)
+		if err == nil {
+			err = mix.Verify(election.ID)
+		}
+		report.add(fmt.Sprintf("mix %d (%s)", i, mix.Node), err)
+		ballots = mix.Ballots
+	}
+
+	// The partials can only be checked against a valid transcript.
+	if len(mixes) == 0 || len(partials) == 0 || transcript != nil {
+		return report, nil
+	}
+
+	n := len(election.Roster.List)
+	t := election.RequiredPartials()
+
+	poly := election.Poly()
+	err = matchShares(election.Shares, partials)
+	report.add("public shares", err)
+	if err != nil {
+		return report, nil
+	}
+
+	mix := mixes[len(mixes)-1]
+	for _, partial := range partials {
+		name := fmt.Sprintf("partial %d (%s)", partial.Index, partial.Node)
+		if err := partial.Verify(election.ID); err != nil {
+			report.add(name, err)
+		} else if partial.Flag {
+			report.add(name, errors.New("node flagged the mixes"))
+		} else {
+			report.add(name, decrypt.VerifyPartial(poly.Eval(partial.Index).V, mix, partial))
+		}
+	}
+
+	points, _, err := decrypt.Reconstruct(poly, mix, partials, t, n)
+	report.add("reconstruction", err)
+	if err != nil || len(results) == 0 {
+		return report, nil
+	}
+
+	recounts, err := tally.Contests(election, mix, points)
+	if err == nil && len(recounts) != len(results) {
+		err = errors.New("stored results do not match the contests")
+	}
+	for i := 0; err == nil && i < len(results); i++ {
+		if !tally.Equal(results[i], recounts[i]) {
+			err = fmt.Errorf("stored result of contest %d does not match recount", i)
+		}
+	}
+	report.add("tally", err)
+
+	return report, nil
+}
+
+// verifyBallot checks the points and, if required, the proof of a ballot.
+func verifyBallot(election *chains.Election, ballot *chains.Ballot) error {
+	if err := chains.ValidPoints(election, nil, ballot); err != nil {
+		return err
+	}
+	return chains.Proven(election, nil, ballot)
+}
+
+// matchShares checks that the public shares of the unflagged partials are the
+// ones published in the DKG transcript.
+func matchShares(shares []kyber.Point, partials []*chains.Partial) error {
+	for _, partial := range partials {
+		if partial.Flag || partial.Public == nil {
+			continue
+		} else if partial.Index < 0 || partial.Index >= len(shares) ||
+			!shares[partial.Index].Equal(partial.Public) {
+			return fmt.Errorf("public share %d does not match the transcript", partial.Index)
+		}
+	}
+	return nil
+}
+
+// parseRoster reads a Dedis group toml file a converts it to a cothority roster.
+func parseRoster(path string) (*onet.Roster, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := app.ReadGroupDescToml(file)
+	if err != nil {
+		return nil, err
+	}
+	return group.Roster, nil
+}
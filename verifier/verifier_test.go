@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dedis/onet"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/qantik/nevv/chains"
+	"github.com/qantik/nevv/crypto"
+	"github.com/qantik/nevv/internal/testutil"
+)
+
+func TestVerify(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.DECRYPTED}
+	_ = election.GenChain(5, testutil.Keys(local, nodes)...)
+
+	report, _ := verify(roster, election.ID)
+	assert.True(t, report.Passed)
+
+	checks := outcomes(report)
+	for _, name := range []string{"transcript", "ballot 0", "ballot 4", "mixnet", "mixes",
+		"public shares", "reconstruction"} {
+		assert.True(t, checks[name], name)
+	}
+	assert.Equal(t, 3, prefixed(report, "mix "))
+	assert.Equal(t, 3, prefixed(report, "partial "))
+}
+
+func TestVerify_MissingMixes(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.RUNNING, MinMixes: 3}
+	_ = election.GenChain(5, testutil.Keys(local, nodes)...)
+
+	report, _ := verify(roster, election.ID)
+	assert.False(t, report.Passed)
+	assert.False(t, outcomes(report)["mixes"])
+}
+
+func TestVerify_Corrupt(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	nodes, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	election := &chains.Election{Roster: roster, Stage: chains.SHUFFLED}
+	_ = election.GenChain(5, testutil.Keys(local, nodes)...)
+	_ = election.Store(&chains.Reference{Kind: chains.MIX_BLOB})
+
+	report, _ := verify(roster, election.ID)
+	assert.False(t, report.Passed)
+}
+
+func TestVerify_InvalidID(t *testing.T) {
+	local := onet.NewLocalTest(crypto.Suite)
+	defer local.CloseAll()
+
+	_, roster, _ := local.GenBigTree(3, 3, 1, true)
+
+	_, err := verify(roster, []byte{})
+	assert.NotNil(t, err)
+}
+
+// outcomes maps the names of the checks of a report to their outcome.
+func outcomes(report *Report) map[string]bool {
+	checks := make(map[string]bool)
+	for _, check := range report.Checks {
+		checks[check.Name] = check.Passed
+	}
+	return checks
+}
+
+// prefixed counts the passed checks of a report whose name has a prefix.
+func prefixed(report *Report, prefix string) int {
+	count := 0
+	for _, check := range report.Checks {
+		if check.Passed && strings.HasPrefix(check.Name, prefix) {
+			count++
+		}
+	}
+	return count
+}